@@ -1,30 +1,85 @@
 package builder
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"io"
 	"io/fs"
 	"log/slog"
 	"os"
+	"path"
 	"path/filepath"
-	"text/template"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/fivethirty/satisficer/internal/builder/internal/config"
+	"github.com/fivethirty/satisficer/internal/builder/internal/data"
+	"github.com/fivethirty/satisficer/internal/builder/internal/funcs"
 	"github.com/fivethirty/satisficer/internal/builder/internal/layout"
+	"github.com/fivethirty/satisficer/internal/builder/internal/manifest"
 	"github.com/fivethirty/satisficer/internal/builder/internal/markdown"
 	"github.com/fivethirty/satisficer/internal/builder/internal/sections"
+	"github.com/fivethirty/satisficer/internal/builder/internal/workers"
 	"github.com/fivethirty/satisficer/internal/fsutil"
 )
 
 type Builder struct {
+	projectFS fs.FS
 	contentFS fs.FS
 	layoutFS  fs.FS
+	dataFS    fs.FS
+	opts      Options
+	cfg       *config.Config
+	manifests map[string]*manifest.Manifest
+	// parsed caches parsed markdown files by a hash of their contents and
+	// of the markdown options they were parsed with.
+	parsed map[string]*markdown.ParsedFile
+}
+
+type Options struct {
+	// CacheDir is where manifests of previous builds are stored so that
+	// repeated builds into the same directory only rewrite outputs whose
+	// inputs changed. When empty, manifests are only kept in memory for the
+	// lifetime of the Builder.
+	CacheDir string
+	// Jobs is the number of pages parsed and rendered concurrently. When zero,
+	// GOMAXPROCS is used.
+	Jobs int
+	// Drafts includes pages marked as drafts in their front matter.
+	Drafts bool
+	// Future includes pages whose createdAt is later than the time of the
+	// build.
+	Future bool
 }
 
 const (
 	LayoutDir  = "layout"
 	ContentDir = "content"
+	DataDir    = "data"
+	// PageTemplate is the template of pages that do not name one, looked up
+	// in the layout directory matching the page's content directory and then
+	// in DefaultTemplateDir.
+	PageTemplate       = "page.html.tmpl"
+	DefaultTemplateDir = "_default"
 )
 
-func New(projectFS fs.FS) (*Builder, error) {
+// version is mixed into every output fingerprint. Bump it whenever a change
+// to the builder alters the output produced from the same inputs.
+const version = "3"
+
+// New creates a Builder for the project in projectFS. It fails if the
+// project's config file is invalid. The config file is read again by every
+// build, so that changes made while the development server runs are picked
+// up.
+func New(projectFS fs.FS, opts Options) (*Builder, error) {
+	cfg, err := config.FromFS(projectFS)
+	if err != nil {
+		return nil, err
+	}
 	layoutFS, err := fs.Sub(projectFS, LayoutDir)
 	if err != nil {
 		return nil, err
@@ -33,13 +88,32 @@ func New(projectFS fs.FS) (*Builder, error) {
 	if err != nil {
 		return nil, err
 	}
+	dataFS, err := fs.Sub(projectFS, DataDir)
+	if err != nil {
+		return nil, err
+	}
 
 	return &Builder{
+		projectFS: projectFS,
 		contentFS: contentFS,
 		layoutFS:  layoutFS,
+		dataFS:    dataFS,
+		opts:      opts,
+		cfg:       cfg,
+		manifests: make(map[string]*manifest.Manifest),
+		parsed:    make(map[string]*markdown.ParsedFile),
 	}, nil
 }
 
+// Port returns the port of the development server set in the config file the
+// Builder was created with, or config.DefaultPort.
+func (b *Builder) Port() uint16 {
+	if b.cfg.Server.Port == 0 {
+		return config.DefaultPort
+	}
+	return uint16(b.cfg.Server.Port)
+}
+
 func validateBuildDir(buildDir string) error {
 	info, err := os.Stat(buildDir)
 	if info != nil && !info.IsDir() {
@@ -51,76 +125,584 @@ func validateBuildDir(buildDir string) error {
 	return nil
 }
 
+// BuildError is returned when content or templates have errors. It holds
+// every error found during the build rather than just the first one, in the
+// same order regardless of how many jobs the build used.
+type BuildError struct {
+	Errs []error
+}
+
+func (e *BuildError) Error() string {
+	if len(e.Errs) == 1 {
+		return e.Errs[0].Error()
+	}
+	msgs := make([]string, 0, len(e.Errs))
+	for _, err := range e.Errs {
+		msgs = append(msgs, err.Error())
+	}
+	return fmt.Sprintf(
+		"build failed with %d errors:\n  %s",
+		len(e.Errs),
+		strings.Join(msgs, "\n  "),
+	)
+}
+
+func (e *BuildError) Unwrap() []error {
+	return e.Errs
+}
+
+// flatten splits errors created with errors.Join into their parts.
+func flatten(err error) []error {
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		return []error{err}
+	}
+	errs := []error{}
+	for _, e := range joined.Unwrap() {
+		errs = append(errs, flatten(e)...)
+	}
+	return errs
+}
+
+type build struct {
+	dir      string
+	manifest *manifest.Manifest
+	mu       sync.Mutex
+	outputs  []output
+	errs     []error
+	written  int
+	skipped  int
+}
+
+// output is a single file in the build directory. Its hash is a fingerprint
+// of every input it is generated from, and write is only called when the
+// output is missing or was generated from different inputs.
+type output struct {
+	path  string
+	hash  string
+	write func(dest string) error
+}
+
+func (bd *build) add(o output) {
+	bd.outputs = append(bd.outputs, o)
+}
+
+// writeAll writes every output that is not fresh using up to jobs workers.
+// When several outputs share a path the one added last wins, as it would if
+// they were written one after the other. Errors are recorded on the build.
+func (bd *build) writeAll(jobs int) {
+	last := make(map[string]int, len(bd.outputs))
+	for i, o := range bd.outputs {
+		last[o.path] = i
+	}
+	outputs := make([]output, 0, len(last))
+	for i, o := range bd.outputs {
+		if last[o.path] == i {
+			outputs = append(outputs, o)
+		}
+	}
+
+	errs := workers.Run(jobs, len(outputs), func(i int) error {
+		return bd.write(outputs[i])
+	})
+	for _, err := range errs {
+		if err != nil {
+			bd.errs = append(bd.errs, err)
+		}
+	}
+}
+
+func (bd *build) write(o output) error {
+	bd.mu.Lock()
+	fresh := bd.manifest.Fresh(bd.dir, o.path, o.hash)
+	if fresh {
+		bd.skipped++
+	}
+	bd.mu.Unlock()
+	if fresh {
+		slog.Debug("Skipping unchanged output", "path", o.path)
+		return nil
+	}
+
+	if err := o.write(filepath.Join(bd.dir, o.path)); err != nil {
+		return err
+	}
+
+	bd.mu.Lock()
+	defer bd.mu.Unlock()
+	bd.written++
+	return bd.manifest.Record(bd.dir, o.path, o.hash)
+}
+
 func (b *Builder) Build(buildDir string) error {
 	slog.Info("Building project", "outputDir", buildDir)
 	if err := validateBuildDir(buildDir); err != nil {
 		return err
 	}
 
-	slog.Info("Loading layout...")
-	l, err := layout.FromFS(b.layoutFS)
+	m, err := b.manifest(buildDir)
 	if err != nil {
 		return err
 	}
+	m.Begin()
+	bd := &build{
+		dir:      buildDir,
+		manifest: m,
+	}
+
+	slog.Info("Loading config...")
+	cfg, err := config.FromFS(b.projectFS)
+	if err != nil {
+		bd.errs = append(bd.errs, err)
+	}
+
+	baseURL := ""
+	mdOpts := markdown.Options{}
+	if cfg != nil {
+		baseURL = cfg.BaseURL
+		mdOpts = markdown.Options{
+			Unsafe:        cfg.Markdown.Unsafe,
+			HardWraps:     cfg.Markdown.HardWraps,
+			Extensions:    markdown.Extensions(cfg.Markdown.Extensions),
+			Highlight:     markdown.Highlight(cfg.Markdown.Highlight),
+			TOC:           markdown.TOC(cfg.Markdown.TOC),
+			SummaryLength: cfg.Markdown.SummaryLength,
+		}
+	}
+	md := markdown.New(mdOpts)
+
+	slog.Info("Loading layout...")
+	l, err := layout.FromFS(b.layoutFS, funcs.New(funcs.Options{
+		BaseURL:     baseURL,
+		Markdownify: md.ToHTML,
+	}))
+	if err != nil {
+		bd.errs = append(bd.errs, flatten(err)...)
+	}
+
+	slog.Info("Loading data...")
+	d, err := data.FromFS(b.dataFS)
+	if err != nil {
+		bd.errs = append(bd.errs, flatten(err)...)
+	}
 
 	slog.Info("Generating content...")
-	s, err := sections.FromFS(b.contentFS, markdown.Parse)
+	parsed := make(map[string]*markdown.ParsedFile, len(b.parsed))
+	parse := b.cachedParse(md, mdOpts, parsed)
+	s, err := sections.FromFS(b.contentFS, func(r io.Reader) (*markdown.ParsedFile, error) {
+		return parse(r, nil)
+	}, b.opts.Jobs)
 	if err != nil {
-		return err
+		bd.errs = append(bd.errs, flatten(err)...)
 	}
+	b.parsed = parsed
+	now := time.Now()
+	b.unpublish(s, now)
+
+	if cfg == nil || l == nil {
+		return &BuildError{Errs: bd.errs}
+	}
+	bd.errs = append(bd.errs, resolveTemplates(s, l, cfg)...)
+	bd.errs = append(bd.errs, b.resolveLinks(s, cfg, parse)...)
+	site := sections.NewSite(s, cfg, now)
+	site.Data = d
 
 	if l.Static != nil {
-		slog.Info("Writing static layout files...")
-		err = fsutil.CopyFS(l.Static, filepath.Join(buildDir, layout.StaticDir))
-		if err != nil {
+		slog.Info("Collecting static layout files...")
+		if err := bd.addFS(l.Static, layout.StaticDir); err != nil {
 			return err
 		}
 	} else {
 		slog.Info("No static layout files found, skipping...")
 	}
 
+	slog.Info("Collecting content...")
+	dirs := make([]string, 0, len(s))
+	for dir := range s {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	hashes, err := b.contentHashes(dirs, s, cfg, d)
+	if err != nil {
+		return err
+	}
+	pageURLs := make(map[string]string, len(site.Pages))
+	for _, page := range site.Pages {
+		pageURLs[page.URL] = page.Source
+	}
+	for _, dir := range dirs {
+		if err := b.addSection(dir, s[dir], l, cfg, hashes, pageURLs, bd); err != nil {
+			return err
+		}
+	}
+	b.addTaxonomies(site, l, hashes, pageURLs, bd)
+	b.addSitemap(s, cfg, bd)
+
 	slog.Info("Writing content...")
-	for _, section := range s {
-		if err := b.writeSection(section, l, buildDir); err != nil {
+	bd.writeAll(b.opts.Jobs)
+
+	if len(bd.errs) > 0 {
+		// Outputs of pages that failed are left as they were, so only
+		// remember what was written instead of pruning everything else.
+		if err := m.Save(); err != nil {
 			return err
 		}
+		return &BuildError{Errs: bd.errs}
 	}
 
-	slog.Info("Project built successfully", "outputDir", buildDir)
+	if err := m.Prune(buildDir); err != nil {
+		return err
+	}
+	if err := m.Save(); err != nil {
+		return err
+	}
+
+	slog.Info(
+		"Project built successfully",
+		"outputDir", buildDir,
+		"written", bd.written,
+		"unchanged", bd.skipped,
+	)
 	return nil
 }
 
-func (b *Builder) writeSection(s *sections.Section, l *layout.Layout, buildDir string) error {
-	for _, file := range s.Files {
-		slog.Info("Copying file", "path", file.URL)
-		if err := fsutil.CopyFile(b.contentFS, file.URL, buildDir); err != nil {
-			return err
+// unpublish removes pages that should not be part of a build started at now
+// from every section, so they are neither rendered nor visible to other
+// pages.
+func (b *Builder) unpublish(s map[string]*sections.Section, now time.Time) {
+	for _, section := range s {
+		published := make(sections.Pages, 0, len(section.Others))
+		for _, page := range section.Others {
+			switch {
+			case page.Draft && !b.opts.Drafts:
+				slog.Info("Skipping draft", "path", page.Source)
+			case page.CreatedAt.After(now) && !b.opts.Future:
+				slog.Info("Skipping future page", "path", page.Source)
+			case page.ExpiresAt != nil && !page.ExpiresAt.After(now):
+				slog.Info("Skipping expired page", "path", page.Source)
+			default:
+				published = append(published, page)
+			}
+		}
+		section.Others = published
+	}
+}
+
+// resolveTemplates sets the template of every page to the first of its
+// templateFiles found in the layout. Pages without a template are removed, so
+// that other pages do not link to them, and reported as errors.
+func resolveTemplates(
+	s map[string]*sections.Section,
+	l *layout.Layout,
+	cfg *config.Config,
+) []error {
+	dirs := make([]string, 0, len(s))
+	for dir := range s {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+
+	errs := []error{}
+	for _, dir := range dirs {
+		section := s[dir]
+		resolved := make(sections.Pages, 0, len(section.Others))
+		for _, page := range section.Others {
+			files := templateFiles(dir, &page, s, cfg)
+			tmpl, err := l.TemplateForContent(page.Source, files...)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			page.UglyURL = page.UglyURL || cfg.Output.UglyURLs
+			page.SetTemplate(tmpl.Name())
+			resolved = append(resolved, page)
+		}
+		section.Others = resolved
+	}
+	return errs
+}
+
+// templateFiles returns the templates that can render page, a page in the
+// section in dir, in order of preference. The page template of dir and of
+// each directory above it is tried, nearest first, up to the first of these
+// sections with a template in its config. The root is only tried for pages
+// in the root, since the root of the layout holds the templates that front
+// matter names. The template named in front matter, in a section config or
+// as the site's default template is used if it exists, and is an error
+// otherwise.
+func templateFiles(
+	dir string,
+	page *sections.Page,
+	s map[string]*sections.Section,
+	cfg *config.Config,
+) []string {
+	if page.Template != "" {
+		return []string{page.Template}
+	}
+	files := []string{}
+	for d := dir; ; d = path.Dir(d) {
+		files = append(files, path.Join(d, PageTemplate))
+		if section, ok := s[d]; ok && section.Config.Template != "" {
+			return append(files, section.Config.Template)
+		}
+		if path.Dir(d) == "." {
+			break
+		}
+	}
+	if cfg.DefaultTemplate != "" {
+		return append(files, cfg.DefaultTemplate)
+	}
+	return append(files, path.Join(DefaultTemplateDir, PageTemplate))
+}
+
+func (b *Builder) manifest(buildDir string) (*manifest.Manifest, error) {
+	abs, err := filepath.Abs(buildDir)
+	if err != nil {
+		return nil, err
+	}
+	if m, ok := b.manifests[abs]; ok {
+		return m, nil
+	}
+	path := ""
+	if b.opts.CacheDir != "" {
+		name := fmt.Sprintf("%s.json", manifest.Hash(abs)[:16])
+		path = filepath.Join(b.opts.CacheDir, "manifests", name)
+	}
+	m, err := manifest.Load(path)
+	if err != nil {
+		return nil, err
+	}
+	b.manifests[abs] = m
+	return m, nil
+}
+
+// parseFunc parses a markdown file, pointing its links to other markdown
+// files at urls as markdown.Markdown.ParseWithURLs does.
+type parseFunc func(r io.Reader, urls map[string]string) (*markdown.ParsedFile, error)
+
+// cachedParse returns a parse function that reuses the results of previous
+// builds for markdown files whose contents, link URLs and markdown options
+// have not changed. Every file parsed is recorded in parsed, which replaces
+// the cache once the build has loaded all content so that removed files do
+// not linger.
+func (b *Builder) cachedParse(
+	md *markdown.Markdown,
+	opts markdown.Options,
+	parsed map[string]*markdown.ParsedFile,
+) parseFunc {
+	previous := b.parsed
+	var mu sync.Mutex
+	return func(r io.Reader, urls map[string]string) (*markdown.ParsedFile, error) {
+		content, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
 		}
+		hash := manifest.Hash(fmt.Sprintf("%+v", opts), fmt.Sprint(urls), string(content))
+		pf, ok := previous[hash]
+		if !ok {
+			pf, err = md.ParseWithURLs(bytes.NewReader(content), urls)
+			if err != nil {
+				return nil, err
+			}
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		parsed[hash] = pf
+		return pf, nil
 	}
+}
 
-	allPages := s.Others
-	for _, page := range allPages {
-		slog.Info("Generating page", "path", page.URL, "from", page.Source)
-		tmpl, err := l.TemplateForContent(page.Source, page.Template)
+func (bd *build) addFS(src fs.FS, destDir string) error {
+	return fs.WalkDir(src, ".", func(p string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
+		if d.IsDir() {
+			return nil
+		}
+		return bd.addFile(src, p, destDir)
+	})
+}
 
-		sectionForPage := s.ForPage(&page)
+func (bd *build) addFile(fsys fs.FS, p string, destDir string) error {
+	hash, err := manifest.HashFile(fsys, p)
+	if err != nil {
+		return err
+	}
+	bd.add(output{
+		path: path.Join(destDir, p),
+		hash: manifest.Hash(version, "file", hash),
+		write: func(_ string) error {
+			return fsutil.CopyFile(fsys, p, filepath.Join(bd.dir, destDir))
+		},
+	})
+	return nil
+}
 
-		path := filepath.Join(buildDir, page.URL)
-		if err := writeContent(tmpl, sectionForPage, path); err != nil {
+func (b *Builder) addSection(
+	dir string,
+	s *sections.Section,
+	l *layout.Layout,
+	cfg *config.Config,
+	hashes *contentHashes,
+	pageURLs map[string]string,
+	bd *build,
+) error {
+	for _, file := range s.Files {
+		if err := bd.addFile(b.contentFS, file.URL, "."); err != nil {
 			return err
 		}
 	}
+
+	sectionHash := hashes.sections[dir]
+	for i := range s.Others {
+		page := &s.Others[i]
+		tmpl := l.Lookup(page.Template)
+
+		// Pages whose templates look beyond their own section have to be
+		// rendered again whenever any content changes.
+		siteHash := ""
+		if l.Uses(tmpl.Name(), crossSectionFields...) {
+			siteHash = hashes.site
+		}
+
+		pagers := []*sections.Paginator{nil}
+		if page == s.Index && s.Config.Paginate > 0 {
+			pagers = s.Pages.ByCreatedAt().Reverse().Paginate(s.Config.Paginate, page.URL)
+		}
+		for _, pager := range pagers {
+			url := page.URL
+			number := 0
+			if pager != nil {
+				url = pager.URL
+				number = pager.Number
+			}
+			if source, ok := pageURLs[url]; ok && number > 1 {
+				bd.errs = append(bd.errs, fmt.Errorf(
+					"%s: page %d of %s conflicts with %s",
+					url,
+					number,
+					page.Source,
+					source,
+				))
+				continue
+			}
+			bd.add(output{
+				path: url,
+				hash: manifest.Hash(
+					version,
+					"page",
+					page.Source,
+					strconv.Itoa(number),
+					sectionHash,
+					siteHash,
+					cfg.BaseURL,
+					l.Fingerprint(tmpl.Name()),
+				),
+				write: func(dest string) error {
+					slog.Info("Generating page", "path", url, "from", page.Source)
+					data := s.ForPage(page)
+					data.Paginator = pager
+					if err := writeContent(tmpl, data, dest); err != nil {
+						return fmt.Errorf("%s: %w", page.Source, err)
+					}
+					return nil
+				},
+			})
+		}
+	}
+
+	b.addFeeds(dir, s, cfg, sectionHash, bd)
 	return nil
 }
 
-func writeContent(tmpl *template.Template, data any, path string) error {
-	dest, err := fsutil.CreateFile(path)
+// crossSectionFields are the fields and methods through which a template can
+// see content outside the section of the page it renders.
+var crossSectionFields = []string{"Site", "Parent", "Children", "Ancestors", "PagesRecursive"}
+
+// contentHashes fingerprint the content visible to the templates of pages.
+type contentHashes struct {
+	// sections holds the hash of each section keyed by directory.
+	sections map[string]string
+	// site is a hash of every section, of the config and of the data.
+	site string
+}
+
+func (b *Builder) contentHashes(
+	dirs []string,
+	s map[string]*sections.Section,
+	cfg *config.Config,
+	d map[string]any,
+) (*contentHashes, error) {
+	hashes := &contentHashes{
+		sections: make(map[string]string, len(dirs)),
+	}
+	// These settings change how every page is rendered, so they are part of
+	// the hash of every section.
+	pageConfig, err := json.Marshal([]any{cfg.DefaultTemplate, cfg.Markdown, cfg.Output})
 	if err != nil {
-		return err
+		return nil, err
+	}
+	siteConfig, err := json.Marshal(cfg.Params)
+	if err != nil {
+		return nil, err
 	}
-	defer func() { _ = dest.Close() }()
-	return tmpl.Execute(dest, data)
+	siteData, err := json.Marshal(d)
+	if err != nil {
+		return nil, err
+	}
+	parts := make([]string, 0, 2*len(dirs)+4)
+	parts = append(parts, cfg.Title, cfg.BaseURL, string(siteConfig), string(siteData))
+	for _, dir := range dirs {
+		hash, err := b.sectionHash(s[dir], string(pageConfig))
+		if err != nil {
+			return nil, err
+		}
+		hashes.sections[dir] = hash
+		parts = append(parts, dir, hash)
+	}
+	hashes.site = manifest.Hash(parts...)
+	return hashes, nil
+}
+
+// sectionHash returns a hash of everything in a section that is visible to
+// the templates of its pages: the source and links of every page, since pages
+// can see their siblings, the list of non-markdown files, the section config and
+// pageConfig, the parts of the project config that apply to every page.
+func (b *Builder) sectionHash(s *sections.Section, pageConfig string) (string, error) {
+	sectionConfig, err := json.Marshal(s.Config)
+	if err != nil {
+		return "", err
+	}
+	parts := make([]string, 0, 2*len(s.Others)+len(s.Files)+2)
+	parts = append(parts, pageConfig, string(sectionConfig))
+	for _, page := range s.Others {
+		hash, err := manifest.HashFile(b.contentFS, page.Source)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, page.Source, hash)
+		// Links change with the URLs of the pages they point to, which may be
+		// in other sections.
+		for _, link := range page.Links {
+			parts = append(parts, link.Dest, link.URL)
+		}
+	}
+	for _, file := range s.Files {
+		parts = append(parts, file.URL)
+	}
+	return manifest.Hash(parts...), nil
+}
+
+func writeContent(tmpl layout.Template, data any, path string) error {
+	return writeOutput(path, func(w io.Writer) error {
+		return tmpl.Execute(w, data)
+	})
+}
+
+// writeOutput replaces the file at path only once render succeeded, so that a
+// failed build leaves the previous output in place, and so that the dev
+// server never serves a partly written file.
+func writeOutput(path string, render func(w io.Writer) error) error {
+	return fsutil.WriteFile(path, render)
 }
@@ -1,14 +1,19 @@
 package builder_test
 
 import (
+	"errors"
+	"fmt"
 	"io/fs"
+	"maps"
 	"os"
+	"path"
 	"path/filepath"
 	"reflect"
 	"sort"
 	"strings"
 	"testing"
 	"testing/fstest"
+	"time"
 
 	"github.com/fivethirty/satisficer/internal/builder"
 	"github.com/fivethirty/satisficer/internal/testutil"
@@ -31,6 +36,12 @@ func projectFS(t *testing.T, layoutFS fstest.MapFS, contentFS fstest.MapFS) fs.F
 	return result
 }
 
+// pageFile returns a markdown file with frontMatter followed by body.
+func pageFile(t *testing.T, frontMatter map[string]any, body string) *fstest.MapFile {
+	t.Helper()
+	return &fstest.MapFile{Data: []byte(testutil.ToContent(t, frontMatter, body))}
+}
+
 func TestGenerate(t *testing.T) {
 	t.Parallel()
 
@@ -177,7 +188,7 @@ func TestGenerate(t *testing.T) {
 		t.Run(test.name, func(t *testing.T) {
 			dir := t.TempDir()
 			pfs := projectFS(t, test.layoutFS, test.contentFS)
-			b, err := builder.New(pfs)
+			b, err := builder.New(pfs, builder.Options{})
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -211,7 +222,12 @@ func TestPageTemplateRendering(t *testing.T) {
 	sb.WriteString("{{ .Current.Title}}\n")
 	sb.WriteString("{{ .Current.CreatedAt}}\n")
 	sb.WriteString("{{ .Current.UpdatedAt}}\n")
+	sb.WriteString("{{ .Current.Params.author}}\n")
 	sb.WriteString("{{ .Current.Content}}\n")
+	sb.WriteString("{{ range .Current.TOC }}{{ .ID }}{{ end }}\n")
+	sb.WriteString("{{ .Current.Summary}}\n")
+	sb.WriteString("{{ .Current.Plain}} {{ .Current.WordCount}} {{ .Current.ReadingTime}}\n")
+	sb.WriteString("{{ .Current.TOCHTML}}\n")
 	pageTemplate := sb.String()
 
 	layoutFS := fstest.MapFS{
@@ -241,6 +257,7 @@ func TestPageTemplateRendering(t *testing.T) {
 							"createdAt": "2025-05-13T00:00:00Z",
 							"updatedAt": "2025-05-14T00:00:00Z",
 							"template":  "page.html.tmpl",
+							"author":    "Jane <jane@example.com>",
 						},
 						"# Test Page Content",
 					),
@@ -250,9 +267,16 @@ func TestPageTemplateRendering(t *testing.T) {
 				htmlPath,
 				mdPath,
 				"Test Page",
-				"2025-05-13 00:00:00 +0000 UTC",
-				"2025-05-14 00:00:00 +0000 UTC",
-				"<h1>Test Page Content</h1>",
+				"2025-05-13 00:00:00 &#43;0000 UTC",
+				"2025-05-14 00:00:00 &#43;0000 UTC",
+				"Jane &lt;jane@example.com&gt;",
+				`<h1 id="test-page-content">Test Page Content</h1>`,
+				"",
+				"test-page-content",
+				"Test Page Content",
+				"Test Page Content 3 1",
+				`<nav class="toc"><ul><li><a href="#test-page-content">` +
+					`Test Page Content</a></li></ul></nav>`,
 			},
 		},
 		{
@@ -274,9 +298,16 @@ func TestPageTemplateRendering(t *testing.T) {
 				htmlPath,
 				mdPath,
 				"Test Page",
-				"2025-05-13 00:00:00 +0000 UTC",
-				"<nil>",
-				"<h1>Test Page Content</h1>",
+				"2025-05-13 00:00:00 &#43;0000 UTC",
+				"&lt;nil&gt;",
+				"",
+				`<h1 id="test-page-content">Test Page Content</h1>`,
+				"",
+				"test-page-content",
+				"Test Page Content",
+				"Test Page Content 3 1",
+				`<nav class="toc"><ul><li><a href="#test-page-content">` +
+					`Test Page Content</a></li></ul></nav>`,
 			},
 		},
 	}
@@ -288,7 +319,7 @@ func TestPageTemplateRendering(t *testing.T) {
 				mdPath: &test.content,
 			}
 			pfs := projectFS(t, layoutFS, contentFS)
-			b, err := builder.New(pfs)
+			b, err := builder.New(pfs, builder.Options{})
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -409,23 +440,23 @@ func TestIndexTemplateRendering(t *testing.T) {
 				htmlPath,
 				mdPath,
 				"Home Page",
-				"2025-05-13 00:00:00 +0000 UTC",
-				"2025-05-14 00:00:00 +0000 UTC",
-				"<h1>Welcome to the Home Page</h1>",
+				"2025-05-13 00:00:00 &#43;0000 UTC",
+				"2025-05-14 00:00:00 &#43;0000 UTC",
+				`<h1 id="welcome-to-the-home-page">Welcome to the Home Page</h1>`,
 				"",
 				"page1/index.html",
 				"page1.md",
 				"Page 1",
-				"2025-05-15 00:00:00 +0000 UTC",
-				"2025-05-16 00:00:00 +0000 UTC",
-				"<h1>Content of Page 1</h1>",
+				"2025-05-15 00:00:00 &#43;0000 UTC",
+				"2025-05-16 00:00:00 &#43;0000 UTC",
+				`<h1 id="content-of-page-1">Content of Page 1</h1>`,
 				"",
 				"page2/index.html",
 				"page2.md",
 				"Page 2",
-				"2025-05-17 00:00:00 +0000 UTC",
-				"2025-05-18 00:00:00 +0000 UTC",
-				"<h1>Content of Page 2</h1>",
+				"2025-05-17 00:00:00 &#43;0000 UTC",
+				"2025-05-18 00:00:00 &#43;0000 UTC",
+				`<h1 id="content-of-page-2">Content of Page 2</h1>`,
 				"",
 				"main.js",
 			},
@@ -436,7 +467,7 @@ func TestIndexTemplateRendering(t *testing.T) {
 		t.Run(test.name, func(t *testing.T) {
 			dir := t.TempDir()
 			pfs := projectFS(t, layoutFS, test.contentFS)
-			g, err := builder.New(pfs)
+			g, err := builder.New(pfs, builder.Options{})
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -459,3 +490,1844 @@ func TestIndexTemplateRendering(t *testing.T) {
 		})
 	}
 }
+
+func TestIncrementalBuild(t *testing.T) {
+	t.Parallel()
+
+	layoutPath := func(p string) string { return path.Join(builder.LayoutDir, p) }
+	contentPath := func(p string) string { return path.Join(builder.ContentDir, p) }
+
+	initial := func() fstest.MapFS {
+		return fstest.MapFS{
+			layoutPath("index.html.tmpl"): {
+				Data: []byte(`{{ template "header.html.tmpl" }}{{ .Current.Title }}`),
+			},
+			layoutPath("page.html.tmpl"):    {Data: []byte("{{ .Current.Title }}")},
+			layoutPath("header.html.tmpl"):  {Data: []byte("header")},
+			layoutPath("unused.html.tmpl"):  {Data: []byte("unused")},
+			layoutPath("another.html.tmpl"): {Data: []byte("another")},
+			layoutPath("site.html.tmpl"):    {Data: []byte("{{ len .Site.Pages }}")},
+			layoutPath("parent.html.tmpl"):  {Data: []byte("{{ .Parent.Path }}")},
+			layoutPath("static/main.css"):   {Data: []byte("body {}")},
+			contentPath("index.md"): pageFile(t, map[string]any{
+				"title":     "Home",
+				"createdAt": "2025-05-13T00:00:00Z",
+				"template":  "index.html.tmpl",
+			}, "# Home"),
+			contentPath("about.md"): pageFile(t, map[string]any{
+				"title":     "About",
+				"createdAt": "2025-05-13T00:00:00Z",
+				"template":  "page.html.tmpl",
+			}, "# About"),
+			contentPath("blog/index.md"): pageFile(t, map[string]any{
+				"title":     "Blog",
+				"createdAt": "2025-05-13T00:00:00Z",
+				"template":  "index.html.tmpl",
+			}, "# Blog"),
+			contentPath("blog/post.md"): pageFile(t, map[string]any{
+				"title":     "Post",
+				"createdAt": "2025-05-13T00:00:00Z",
+				"template":  "page.html.tmpl",
+			}, "# Post"),
+			contentPath("blog/logo.png"): {Data: []byte("png")},
+			contentPath("archive/index.md"): pageFile(t, map[string]any{
+				"title":     "Archive",
+				"createdAt": "2025-05-13T00:00:00Z",
+				"template":  "site.html.tmpl",
+			}, "# Archive"),
+			contentPath("blog/nested/page.md"): pageFile(t, map[string]any{
+				"title":     "Nested",
+				"createdAt": "2025-05-13T00:00:00Z",
+				"template":  "parent.html.tmpl",
+			}, "# Nested"),
+		}
+	}
+
+	tests := []struct {
+		name        string
+		change      func(t *testing.T, pfs fstest.MapFS, dir string)
+		wantWritten []string
+		wantRemoved []string
+	}{
+		{
+			name:        "nothing changed",
+			change:      func(_ *testing.T, _ fstest.MapFS, _ string) {},
+			wantWritten: []string{},
+		},
+		{
+			name: "page changed",
+			change: func(_ *testing.T, pfs fstest.MapFS, _ string) {
+				pfs[contentPath("blog/post.md")] = pageFile(t, map[string]any{
+					"title":     "New Post",
+					"createdAt": "2025-05-13T00:00:00Z",
+					"template":  "page.html.tmpl",
+				}, "# New Post")
+			},
+			wantWritten: []string{
+				"archive/index.html",
+				"blog/index.html",
+				"blog/post/index.html",
+				"blog/nested/page/index.html",
+			},
+		},
+		{
+			name: "page added",
+			change: func(_ *testing.T, pfs fstest.MapFS, _ string) {
+				pfs[contentPath("blog/new.md")] = pageFile(t, map[string]any{
+					"title":     "New",
+					"createdAt": "2025-05-13T00:00:00Z",
+					"template":  "page.html.tmpl",
+				}, "# New")
+			},
+			wantWritten: []string{
+				"archive/index.html",
+				"blog/index.html",
+				"blog/post/index.html",
+				"blog/new/index.html",
+				"blog/nested/page/index.html",
+			},
+		},
+		{
+			name: "page removed",
+			change: func(_ *testing.T, pfs fstest.MapFS, _ string) {
+				delete(pfs, contentPath("about.md"))
+			},
+			wantWritten: []string{
+				"archive/index.html",
+				"index.html",
+				"blog/nested/page/index.html",
+			},
+			wantRemoved: []string{"about/index.html"},
+		},
+		{
+			name: "page switched template",
+			change: func(_ *testing.T, pfs fstest.MapFS, _ string) {
+				pfs[contentPath("about.md")] = pageFile(t, map[string]any{
+					"title":     "About",
+					"createdAt": "2025-05-13T00:00:00Z",
+					"template":  "another.html.tmpl",
+				}, "# About")
+			},
+			wantWritten: []string{
+				"archive/index.html",
+				"index.html",
+				"about/index.html",
+				"blog/nested/page/index.html",
+			},
+		},
+		{
+			name: "other section changed",
+			change: func(_ *testing.T, pfs fstest.MapFS, _ string) {
+				pfs[contentPath("index.md")] = pageFile(t, map[string]any{
+					"title":     "New Home",
+					"createdAt": "2025-05-13T00:00:00Z",
+					"template":  "index.html.tmpl",
+				}, "# New Home")
+			},
+			wantWritten: []string{
+				"archive/index.html",
+				"index.html",
+				"about/index.html",
+				"blog/nested/page/index.html",
+			},
+		},
+		{
+			name: "site config changed",
+			change: func(_ *testing.T, pfs fstest.MapFS, _ string) {
+				pfs["satisficer.json"] = &fstest.MapFile{Data: []byte(`{"title": "Site"}`)}
+			},
+			wantWritten: []string{"archive/index.html", "blog/nested/page/index.html"},
+		},
+		{
+			name: "markdown config changed",
+			change: func(_ *testing.T, pfs fstest.MapFS, _ string) {
+				pfs["satisficer.json"] = &fstest.MapFile{
+					Data: []byte(`{"markdown": {"hardWraps": true}}`),
+				}
+			},
+			wantWritten: []string{
+				"archive/index.html",
+				"index.html",
+				"about/index.html",
+				"blog/index.html",
+				"blog/post/index.html",
+				"blog/nested/page/index.html",
+			},
+		},
+		{
+			name: "server config changed",
+			change: func(_ *testing.T, pfs fstest.MapFS, _ string) {
+				pfs["satisficer.json"] = &fstest.MapFile{
+					Data: []byte(`{"server": {"port": 8080}}`),
+				}
+			},
+			wantWritten: []string{},
+		},
+		{
+			name: "data changed",
+			change: func(_ *testing.T, pfs fstest.MapFS, _ string) {
+				pfs[path.Join(builder.DataDir, "team.json")] = &fstest.MapFile{
+					Data: []byte(`["Jane"]`),
+				}
+			},
+			wantWritten: []string{"archive/index.html", "blog/nested/page/index.html"},
+		},
+		{
+			name: "template changed",
+			change: func(_ *testing.T, pfs fstest.MapFS, _ string) {
+				pfs[layoutPath("page.html.tmpl")] = &fstest.MapFile{Data: []byte("changed")}
+			},
+			wantWritten: []string{"about/index.html", "blog/post/index.html"},
+		},
+		{
+			name: "invoked template changed",
+			change: func(_ *testing.T, pfs fstest.MapFS, _ string) {
+				pfs[layoutPath("header.html.tmpl")] = &fstest.MapFile{Data: []byte("changed")}
+			},
+			wantWritten: []string{"index.html", "blog/index.html"},
+		},
+		{
+			name: "unused template changed",
+			change: func(_ *testing.T, pfs fstest.MapFS, _ string) {
+				pfs[layoutPath("unused.html.tmpl")] = &fstest.MapFile{Data: []byte("changed")}
+			},
+			wantWritten: []string{},
+		},
+		{
+			name: "content file changed",
+			change: func(_ *testing.T, pfs fstest.MapFS, _ string) {
+				pfs[contentPath("blog/logo.png")] = &fstest.MapFile{Data: []byte("new png")}
+			},
+			wantWritten: []string{"blog/logo.png"},
+		},
+		{
+			name: "static file changed",
+			change: func(_ *testing.T, pfs fstest.MapFS, _ string) {
+				pfs[layoutPath("static/main.css")] = &fstest.MapFile{Data: []byte("html {}")}
+			},
+			wantWritten: []string{"static/main.css"},
+		},
+		{
+			name: "output deleted",
+			change: func(t *testing.T, _ fstest.MapFS, dir string) {
+				if err := os.Remove(filepath.Join(dir, "about/index.html")); err != nil {
+					t.Fatal(err)
+				}
+			},
+			wantWritten: []string{"about/index.html"},
+		},
+	}
+
+	for _, test := range tests {
+		for _, persisted := range []bool{false, true} {
+			name := test.name
+			if persisted {
+				name += " across builders"
+			}
+			t.Run(name, func(t *testing.T) {
+				t.Parallel()
+
+				dir := t.TempDir()
+				pfs := initial()
+				opts := builder.Options{}
+				if persisted {
+					opts.CacheDir = t.TempDir()
+				}
+
+				b, err := builder.New(pfs, opts)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := b.Build(dir); err != nil {
+					t.Fatal(err)
+				}
+				before := modTimes(t, dir)
+
+				// Make sure any rewritten file gets a different modification time.
+				time.Sleep(20 * time.Millisecond)
+
+				test.change(t, pfs, dir)
+				if persisted {
+					b, err = builder.New(pfs, opts)
+					if err != nil {
+						t.Fatal(err)
+					}
+				}
+				if err := b.Build(dir); err != nil {
+					t.Fatal(err)
+				}
+				after := modTimes(t, dir)
+
+				written := []string{}
+				for path, modTime := range after {
+					if prev, ok := before[path]; !ok || !prev.Equal(modTime) {
+						written = append(written, path)
+					}
+				}
+				removed := []string{}
+				for path := range before {
+					if _, ok := after[path]; !ok {
+						removed = append(removed, path)
+					}
+				}
+
+				sort.Strings(written)
+				sort.Strings(test.wantWritten)
+				if !reflect.DeepEqual(written, test.wantWritten) {
+					t.Fatalf("expected written %v, got %v", test.wantWritten, written)
+				}
+				wantRemoved := test.wantRemoved
+				if wantRemoved == nil {
+					wantRemoved = []string{}
+				}
+				sort.Strings(removed)
+				sort.Strings(wantRemoved)
+				if !reflect.DeepEqual(removed, wantRemoved) {
+					t.Fatalf("expected removed %v, got %v", wantRemoved, removed)
+				}
+			})
+		}
+	}
+}
+
+func modTimes(t *testing.T, dir string) map[string]time.Time {
+	t.Helper()
+	result := make(map[string]time.Time)
+	for _, p := range testutil.SortedPaths(t, os.DirFS(dir)) {
+		info, err := os.Stat(filepath.Join(dir, p))
+		if err != nil {
+			t.Fatal(err)
+		}
+		result[p] = info.ModTime()
+	}
+	return result
+}
+
+func TestParallelBuild(t *testing.T) {
+	t.Parallel()
+
+	layoutFS := fstest.MapFS{
+		"index.html.tmpl": {
+			Data: []byte("{{ .Current.Title }}{{ range .Others }} {{ .Title }}{{ end }}"),
+		},
+		"page.html.tmpl": {Data: []byte("{{ .Current.Title }}")},
+	}
+	contentFS := fstest.MapFS{}
+	for i := range 50 {
+		dir := fmt.Sprintf("section%d", i%5)
+		template := "page.html.tmpl"
+		if i%10 == 0 {
+			template = "index.html.tmpl"
+		}
+		contentFS[fmt.Sprintf("%s/page%02d.md", dir, i)] = pageFile(t, map[string]any{
+			"title":     fmt.Sprintf("Page %d", i),
+			"createdAt": "2025-05-13T00:00:00Z",
+			"template":  template,
+		}, fmt.Sprintf("# Page %d", i))
+	}
+
+	build := func(t *testing.T, contentFS fstest.MapFS, jobs int) (string, error) {
+		t.Helper()
+		dir := t.TempDir()
+		pfs := projectFS(t, layoutFS, contentFS)
+		b, err := builder.New(pfs, builder.Options{Jobs: jobs})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return dir, b.Build(dir)
+	}
+
+	t.Run("output does not depend on number of jobs", func(t *testing.T) {
+		t.Parallel()
+
+		want, err := build(t, contentFS, 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		wantPaths := testutil.SortedPaths(t, os.DirFS(want))
+		for _, jobs := range []int{0, 2, 8, 64} {
+			got, err := build(t, contentFS, jobs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			gotPaths := testutil.SortedPaths(t, os.DirFS(got))
+			if !reflect.DeepEqual(gotPaths, wantPaths) {
+				t.Fatalf("jobs=%d: expected paths %v, got %v", jobs, wantPaths, gotPaths)
+			}
+			for _, p := range wantPaths {
+				wantContent, err := os.ReadFile(filepath.Join(want, p))
+				if err != nil {
+					t.Fatal(err)
+				}
+				gotContent, err := os.ReadFile(filepath.Join(got, p))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if string(gotContent) != string(wantContent) {
+					t.Fatalf(
+						"jobs=%d: expected %s to be %q, got %q",
+						jobs,
+						p,
+						wantContent,
+						gotContent,
+					)
+				}
+			}
+		}
+	})
+
+	t.Run("errors do not depend on number of jobs", func(t *testing.T) {
+		t.Parallel()
+
+		broken := fstest.MapFS{}
+		maps.Copy(broken, contentFS)
+		broken["section1/page11.md"] = &fstest.MapFile{Data: []byte("no front matter")}
+		broken["section3/page33.md"] = &fstest.MapFile{Data: []byte("---\n{invalid}\n---\n")}
+		broken["section4/page44.md"] = &fstest.MapFile{Data: []byte("---\n{}\n---\n")}
+
+		_, wantErr := build(t, broken, 1)
+		if wantErr == nil {
+			t.Fatal("expected an error but got none")
+		}
+		for _, jobs := range []int{0, 2, 8, 64} {
+			_, err := build(t, broken, jobs)
+			if err == nil || err.Error() != wantErr.Error() {
+				t.Fatalf("jobs=%d: expected error %v, got %v", jobs, wantErr, err)
+			}
+		}
+	})
+}
+
+func TestBuildErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		layoutFS fstest.MapFS
+		content  fstest.MapFS
+		wantErrs []string
+	}{
+		{
+			name: "reports every content and template error",
+			layoutFS: fstest.MapFS{
+				"page.html.tmpl":   {Data: []byte("{{ .Current.Title }}")},
+				"broken.html.tmpl": {Data: []byte("{{ .Current.Nope }}")},
+			},
+			content: fstest.MapFS{
+				"a.md": pageFile(t, map[string]any{
+					"title":     "Page",
+					"createdAt": "2025-05-13T00:00:00Z",
+					"template":  "page.html.tmpl",
+				}, "# Page"),
+				"b.md": {Data: []byte("no front matter")},
+				"c.md": {Data: []byte("---\n{\n  \"title\": 1\n}\n---\n")},
+				"d.md": {Data: []byte("---\n{\n  invalid\n}\n---\n")},
+				"e.md": {Data: []byte("---\n{}\n---\n")},
+				"f.md": pageFile(t, map[string]any{
+					"title":     "Page",
+					"createdAt": "2025-05-13T00:00:00Z",
+					"template":  "missing.html.tmpl",
+				}, "# Page"),
+				"blog/g.md": pageFile(t, map[string]any{
+					"title":     "Page",
+					"createdAt": "2025-05-13T00:00:00Z",
+					"template":  "broken.html.tmpl",
+				}, "# Page"),
+				"blog/h.md": pageFile(t, map[string]any{
+					"title":     "Page",
+					"createdAt": "2025-05-13T00:00:00Z",
+					"template":  "page.html.tmpl",
+				}, "# Page"),
+				"blog/img.md": pageFile(t, map[string]any{
+					"title":     "Page",
+					"createdAt": "2025-05-13T00:00:00Z",
+					"template":  "also-missing.html.tmpl",
+				}, "# Page"),
+				"blog/i.md": pageFile(t, map[string]any{
+					"title":     "Page",
+					"createdAt": "2025-05-13T00:00:00Z",
+				}, "# Page"),
+				"blog/2024/k.md": pageFile(t, map[string]any{
+					"title":     "Page",
+					"createdAt": "2025-05-13T00:00:00Z",
+				}, "# Page"),
+				"docs/j.md": pageFile(t, map[string]any{
+					"title":     "Page",
+					"createdAt": "2025-05-13T00:00:00Z",
+				}, "# Page"),
+				"docs/_section.json": {
+					Data: []byte(`{"template": "docs.html.tmpl"}`),
+				},
+			},
+			wantErrs: []string{
+				"b.md:1:1: could not find front matter",
+				"c.md:3:12: json: cannot unmarshal number",
+				"d.md:3:3: invalid character 'i'",
+				"e.md: missing required front matter fields: title, created-at",
+				"f.md: template missing.html.tmpl not found",
+				"blog/i.md: no template found, tried blog/page.html.tmpl, " +
+					"_default/page.html.tmpl",
+				"blog/img.md: template also-missing.html.tmpl not found",
+				"blog/2024/k.md: no template found, tried blog/2024/page.html.tmpl, " +
+					"blog/page.html.tmpl, _default/page.html.tmpl",
+				"docs/j.md: no template found, tried docs/page.html.tmpl, docs.html.tmpl",
+				"blog/g.md: template: broken.html.tmpl:1:11: executing",
+			},
+		},
+		{
+			name: "reports every template syntax error alongside content errors",
+			layoutFS: fstest.MapFS{
+				"a.html.tmpl": {Data: []byte("{{ .Current.Title ")},
+				"b.html.tmpl": {Data: []byte("{{ end }}")},
+			},
+			content: fstest.MapFS{
+				"a.md": {Data: []byte("no front matter")},
+			},
+			wantErrs: []string{
+				"template: a.html.tmpl:1: unclosed action",
+				"template: b.html.tmpl:1: unexpected {{end}}",
+				"a.md:1:1: could not find front matter",
+			},
+		},
+		{
+			name: "reports links to content that is not rendered",
+			layoutFS: fstest.MapFS{
+				"page.html.tmpl": {Data: []byte("{{ .Current.Content }}")},
+			},
+			content: fstest.MapFS{
+				"a.md": pageFile(
+					t,
+					map[string]any{"title": "A", "createdAt": "2025-05-13T00:00:00Z"},
+					"See [b](b.md) and\n[the draft](blog/draft.md#top).",
+				),
+				"blog/draft.md": pageFile(t, map[string]any{
+					"title":     "D",
+					"createdAt": "2025-05-13T00:00:00Z",
+					"draft":     true,
+				}, "Draft"),
+			},
+			wantErrs: []string{
+				"a.md:4:5: link to b.md: b.md does not exist",
+				"a.md:5:1: link to blog/draft.md#top: blog/draft.md is not published",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			for _, jobs := range []int{1, 8} {
+				pfs := projectFS(t, test.layoutFS, test.content)
+				b, err := builder.New(pfs, builder.Options{Jobs: jobs})
+				if err != nil {
+					t.Fatal(err)
+				}
+				err = b.Build(t.TempDir())
+
+				var buildErr *builder.BuildError
+				if !errors.As(err, &buildErr) {
+					t.Fatalf("expected a BuildError, got %v", err)
+				}
+				if len(buildErr.Errs) != len(test.wantErrs) {
+					t.Fatalf(
+						"expected %d errors, got %d: %v",
+						len(test.wantErrs),
+						len(buildErr.Errs),
+						err,
+					)
+				}
+				for i, want := range test.wantErrs {
+					if got := buildErr.Errs[i].Error(); !strings.HasPrefix(got, want) {
+						t.Fatalf("expected error %d to start with %q, got %q", i, want, got)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestDrafts(t *testing.T) {
+	t.Parallel()
+
+	layoutFS := fstest.MapFS{
+		"index.html.tmpl": {
+			Data: []byte("{{ range .Others.ByTitle }}{{ .Title }}\n{{ end }}"),
+		},
+		"page.html.tmpl": {Data: []byte("{{ .Current.Title }}")},
+	}
+	contentFS := fstest.MapFS{
+		"index.md": pageFile(t, map[string]any{
+			"title":     "Home",
+			"createdAt": "2025-05-13T00:00:00Z",
+			"template":  "index.html.tmpl",
+		}, "# Home"),
+		"published.md": pageFile(t, map[string]any{
+			"title":     "Published",
+			"createdAt": "2025-05-13T00:00:00Z",
+			"template":  "page.html.tmpl",
+		}, "# Published"),
+		"draft.md": pageFile(t, map[string]any{
+			"title":     "Draft",
+			"createdAt": "2025-05-13T00:00:00Z",
+			"template":  "page.html.tmpl",
+			"draft":     true,
+		}, "# Draft"),
+	}
+
+	tests := []struct {
+		name      string
+		drafts    bool
+		wantPaths []string
+		wantIndex string
+	}{
+		{
+			name:      "excludes drafts by default",
+			wantPaths: []string{"index.html", "published/index.html"},
+			wantIndex: "Published\n",
+		},
+		{
+			name:   "includes drafts when enabled",
+			drafts: true,
+			wantPaths: []string{
+				"draft/index.html",
+				"index.html",
+				"published/index.html",
+			},
+			wantIndex: "Draft\nPublished\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			pfs := projectFS(t, layoutFS, contentFS)
+			b, err := builder.New(pfs, builder.Options{Drafts: test.drafts})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := b.Build(dir); err != nil {
+				t.Fatal(err)
+			}
+
+			paths := testutil.SortedPaths(t, os.DirFS(dir))
+			if !reflect.DeepEqual(paths, test.wantPaths) {
+				t.Fatalf("expected paths %v, got %v", test.wantPaths, paths)
+			}
+			index, err := os.ReadFile(filepath.Join(dir, "index.html"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(index) != test.wantIndex {
+				t.Fatalf("expected index %q, got %q", test.wantIndex, index)
+			}
+		})
+	}
+}
+
+func TestScheduledPublishing(t *testing.T) {
+	t.Parallel()
+
+	layoutFS := fstest.MapFS{
+		"page.html.tmpl": {
+			Data: []byte("{{ range .Others.ByTitle }}{{ .Title }}\n{{ end }}"),
+		},
+	}
+	contentFS := fstest.MapFS{
+		"index.md": pageFile(t, map[string]any{
+			"title":     "Home",
+			"createdAt": "2000-01-01T00:00:00Z",
+			"template":  "page.html.tmpl",
+		}, "# Home"),
+		"past.md": pageFile(t, map[string]any{
+			"title":     "Past",
+			"createdAt": "2000-01-01T00:00:00Z",
+			"template":  "page.html.tmpl",
+		}, "# Past"),
+		"future.md": pageFile(t, map[string]any{
+			"title":     "Future",
+			"createdAt": "2999-01-01T00:00:00Z",
+			"template":  "page.html.tmpl",
+		}, "# Future"),
+		"expired.md": pageFile(t, map[string]any{
+			"title":     "Expired",
+			"createdAt": "2000-01-01T00:00:00Z",
+			"template":  "page.html.tmpl",
+			"expiresAt": "2001-01-01T00:00:00Z",
+		}, "# Expired"),
+		"expiring.md": pageFile(t, map[string]any{
+			"title":     "Expiring",
+			"createdAt": "2000-01-01T00:00:00Z",
+			"template":  "page.html.tmpl",
+			"expiresAt": "2999-01-01T00:00:00Z",
+		}, "# Expiring"),
+	}
+
+	tests := []struct {
+		name      string
+		future    bool
+		wantPaths []string
+		wantIndex string
+	}{
+		{
+			name: "withholds future and expired pages",
+			wantPaths: []string{
+				"expiring/index.html",
+				"index.html",
+				"past/index.html",
+			},
+			wantIndex: "Expiring\nPast\n",
+		},
+		{
+			name:   "includes future pages when enabled",
+			future: true,
+			wantPaths: []string{
+				"expiring/index.html",
+				"future/index.html",
+				"index.html",
+				"past/index.html",
+			},
+			wantIndex: "Expiring\nFuture\nPast\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			pfs := projectFS(t, layoutFS, contentFS)
+			b, err := builder.New(pfs, builder.Options{Future: test.future})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := b.Build(dir); err != nil {
+				t.Fatal(err)
+			}
+
+			paths := testutil.SortedPaths(t, os.DirFS(dir))
+			if !reflect.DeepEqual(paths, test.wantPaths) {
+				t.Fatalf("expected paths %v, got %v", test.wantPaths, paths)
+			}
+			index, err := os.ReadFile(filepath.Join(dir, "index.html"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(index) != test.wantIndex {
+				t.Fatalf("expected index %q, got %q", test.wantIndex, index)
+			}
+		})
+	}
+}
+
+func TestFeeds(t *testing.T) {
+	t.Parallel()
+
+	layoutFS := fstest.MapFS{
+		"page.html.tmpl": {Data: []byte("{{ .Current.Title }}")},
+	}
+	posts := fstest.MapFS{
+		"index.md": pageFile(t, map[string]any{
+			"title":     "Home",
+			"createdAt": "2025-01-01T00:00:00Z",
+			"template":  "page.html.tmpl",
+		}, "Content of Home"),
+		"posts/index.md": pageFile(t, map[string]any{
+			"title":     "All Posts",
+			"createdAt": "2025-01-01T00:00:00Z",
+			"template":  "page.html.tmpl",
+		}, "Content of All Posts"),
+		"posts/first.md": pageFile(t, map[string]any{
+			"title":     "First",
+			"createdAt": "2025-01-02T00:00:00Z",
+			"template":  "page.html.tmpl",
+		}, "Content of First"),
+		"posts/second.md": pageFile(t, map[string]any{
+			"title":     "Second",
+			"createdAt": "2025-01-03T00:00:00Z",
+			"template":  "page.html.tmpl",
+		}, "Content of Second"),
+		"posts/third.md": pageFile(t, map[string]any{
+			"title":     "Third",
+			"createdAt": "2025-01-04T00:00:00Z",
+			"template":  "page.html.tmpl",
+		}, "Content of Third"),
+		"notes/only-note.md": pageFile(t, map[string]any{
+			"title":     "Note",
+			"createdAt": "2025-01-04T00:00:00Z",
+			"template":  "page.html.tmpl",
+		}, "Content of Note"),
+		"notes/_section.json": {Data: []byte(`{"feeds": {"atom": true}}`)},
+	}
+	withConfig := func(contentFS fstest.MapFS, cfg string, section string) fs.FS {
+		pfs := projectFS(t, layoutFS, contentFS).(fstest.MapFS)
+		if cfg != "" {
+			pfs["satisficer.json"] = &fstest.MapFile{Data: []byte(cfg)}
+		}
+		pfs[path.Join(builder.ContentDir, "posts/_section.json")] = &fstest.MapFile{
+			Data: []byte(section),
+		}
+		return pfs
+	}
+	siteConfig := `{"title": "My Site", "baseURL": "https://example.com/"}`
+
+	tests := []struct {
+		name      string
+		projectFS fs.FS
+		wantFeeds map[string][]string
+		wantError string
+	}{
+		{
+			name: "generates enabled feeds",
+			projectFS: withConfig(
+				posts,
+				siteConfig,
+				`{"feeds": {"rss": true, "atom": true}}`,
+			),
+			wantFeeds: map[string][]string{
+				"posts/index.xml": {
+					"<title>All Posts</title>",
+					"<link>https://example.com/posts/</link>",
+					"<link>https://example.com/posts/third/</link>",
+					"<description>&lt;p&gt;Content of Third&lt;/p&gt;&#xA;</description>",
+				},
+				"posts/atom.xml": {
+					`<link href="https://example.com/posts/atom.xml" rel="self"`,
+					"<id>https://example.com/posts/first/</id>",
+				},
+				"notes/atom.xml": {
+					"<title>My Site</title>",
+					"<id>https://example.com/notes/only-note/</id>",
+				},
+			},
+		},
+		{
+			name: "limits number of entries to the newest pages",
+			projectFS: withConfig(
+				posts,
+				siteConfig,
+				`{"feeds": {"rss": true, "limit": 2}}`,
+			),
+			wantFeeds: map[string][]string{
+				"posts/index.xml": {
+					"<link>https://example.com/posts/third/</link>",
+					"<link>https://example.com/posts/second/</link>",
+				},
+				"notes/atom.xml": {},
+			},
+		},
+		{
+			name: "requires a base url",
+			projectFS: withConfig(
+				posts,
+				"",
+				`{"feeds": {"rss": true}}`,
+			),
+			wantError: "posts/_section.json: feeds require a baseURL in satisficer.json",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			b, err := builder.New(test.projectFS, builder.Options{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = b.Build(dir)
+			if test.wantError != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantError) {
+					t.Fatalf("expected error %q, got %v", test.wantError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, p := range testutil.SortedPaths(t, os.DirFS(dir)) {
+				if !strings.HasSuffix(p, ".xml") {
+					continue
+				}
+				if _, ok := test.wantFeeds[p]; !ok {
+					t.Fatalf("unexpected feed %s", p)
+				}
+			}
+			for p, wantContains := range test.wantFeeds {
+				content, err := os.ReadFile(filepath.Join(dir, p))
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, want := range wantContains {
+					if !strings.Contains(string(content), want) {
+						t.Fatalf("expected %s to contain %q, got:\n%s", p, want, content)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestSitemap(t *testing.T) {
+	t.Parallel()
+
+	layoutFS := fstest.MapFS{
+		"page.html.tmpl": {Data: []byte("{{ .Current.Title }}")},
+	}
+	contentFS := fstest.MapFS{
+		"index.md": pageFile(t, map[string]any{
+			"title":     "Title",
+			"createdAt": "2025-01-01T00:00:00Z",
+			"template":  "page.html.tmpl",
+		}, "Content"),
+		"about.md": pageFile(t, map[string]any{
+			"title":     "Title",
+			"createdAt": "2025-01-01T00:00:00Z",
+			"updatedAt": "2025-02-01T00:00:00Z",
+			"template":  "page.html.tmpl",
+			"uglyURL":   true,
+		}, "Content"),
+		"posts/first.md": pageFile(t, map[string]any{
+			"title":     "Title",
+			"createdAt": "2025-01-02T00:00:00Z",
+			"template":  "page.html.tmpl",
+		}, "Content"),
+		"posts/hidden.md": pageFile(t, map[string]any{
+			"title":     "Title",
+			"createdAt": "2025-01-03T00:00:00Z",
+			"template":  "page.html.tmpl",
+			"noSitemap": true,
+		}, "Content"),
+		"posts/draft.md": pageFile(t, map[string]any{
+			"title":     "Title",
+			"createdAt": "2025-01-04T00:00:00Z",
+			"template":  "page.html.tmpl",
+			"draft":     true,
+		}, "Content"),
+		"logo.png": {Data: []byte("png")},
+	}
+
+	tests := []struct {
+		name      string
+		config    string
+		want      string
+		wantError string
+	}{
+		{
+			name:   "lists every published page",
+			config: `{"baseURL": "https://example.com", "sitemap": true}`,
+			want: `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url>
+    <loc>https://example.com/</loc>
+    <lastmod>2025-01-01T00:00:00Z</lastmod>
+  </url>
+  <url>
+    <loc>https://example.com/about.html</loc>
+    <lastmod>2025-02-01T00:00:00Z</lastmod>
+  </url>
+  <url>
+    <loc>https://example.com/posts/first/</loc>
+    <lastmod>2025-01-02T00:00:00Z</lastmod>
+  </url>
+</urlset>
+`,
+		},
+		{
+			name:   "is not generated unless enabled",
+			config: `{"baseURL": "https://example.com"}`,
+		},
+		{
+			name:      "requires a base url",
+			config:    `{"sitemap": true}`,
+			wantError: "satisficer.json: sitemap requires a baseURL",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			pfs := projectFS(t, layoutFS, contentFS).(fstest.MapFS)
+			pfs["satisficer.json"] = &fstest.MapFile{Data: []byte(test.config)}
+
+			dir := t.TempDir()
+			b, err := builder.New(pfs, builder.Options{})
+			if err == nil {
+				err = b.Build(dir)
+			}
+			if test.wantError != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantError) {
+					t.Fatalf("expected error %q, got %v", test.wantError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			content, err := os.ReadFile(filepath.Join(dir, builder.SitemapFile))
+			if test.want == "" {
+				if !os.IsNotExist(err) {
+					t.Fatalf("expected no sitemap, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(content) != test.want {
+				t.Fatalf("got:\n%s\nwant:\n%s", content, test.want)
+			}
+		})
+	}
+}
+
+func TestSite(t *testing.T) {
+	t.Parallel()
+
+	layoutFS := fstest.MapFS{
+		"home.html.tmpl": {
+			Data: []byte(
+				"{{ .Site.Title }} {{ .Site.BaseURL }}\n" +
+					`{{ range (index .Site.Sections "posts").Others.ByCreatedAt.Reverse }}` +
+					"{{ .Title }}\n{{ end }}" +
+					"{{ range .Site.Pages }}{{ .Source }}\n{{ end }}" +
+					"{{ if .Site.BuildTime.IsZero }}no build time{{ end }}",
+			),
+		},
+		"page.html.tmpl": {Data: []byte("{{ .Current.Title }}")},
+	}
+	contentFS := fstest.MapFS{
+		"index.md": pageFile(t, map[string]any{
+			"title":     "Home",
+			"createdAt": "2025-01-01T00:00:00Z",
+			"template":  "home.html.tmpl",
+		}, "Content"),
+		"about.md": pageFile(t, map[string]any{
+			"title":     "About",
+			"createdAt": "2025-01-01T00:00:00Z",
+			"template":  "page.html.tmpl",
+		}, "Content"),
+		"posts/first.md": pageFile(t, map[string]any{
+			"title":     "First",
+			"createdAt": "2025-01-02T00:00:00Z",
+			"template":  "page.html.tmpl",
+		}, "Content"),
+		"posts/second.md": pageFile(t, map[string]any{
+			"title":     "Second",
+			"createdAt": "2025-01-03T00:00:00Z",
+			"template":  "page.html.tmpl",
+		}, "Content"),
+		"posts/draft.md": pageFile(t, map[string]any{
+			"title":     "Draft",
+			"createdAt": "2025-01-04T00:00:00Z",
+			"template":  "page.html.tmpl",
+			"draft":     true,
+		}, "Content"),
+	}
+	pfs := projectFS(t, layoutFS, contentFS).(fstest.MapFS)
+	pfs["satisficer.json"] = &fstest.MapFile{
+		Data: []byte(`{"title": "My Site", "baseURL": "https://example.com"}`),
+	}
+
+	dir := t.TempDir()
+	b, err := builder.New(pfs, builder.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Build(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, "index.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "My Site https://example.com\n" +
+		"Second\n" +
+		"First\n" +
+		"about.md\n" +
+		"index.md\n" +
+		"posts/first.md\n" +
+		"posts/second.md\n"
+	if string(content) != want {
+		t.Fatalf("got:\n%s\nwant:\n%s", content, want)
+	}
+}
+
+func TestSectionHierarchy(t *testing.T) {
+	t.Parallel()
+
+	layoutFS := fstest.MapFS{
+		"section.html.tmpl": {
+			Data: []byte(
+				"{{ range .Ancestors }}{{ .Index.Title }} > {{ end }}{{ .Current.Title }}\n" +
+					"{{ range .Children }}{{ .Path }}: {{ len .Pages }}\n{{ end }}" +
+					"{{ range .PagesRecursive.ByTitle }}{{ .Title }}\n{{ end }}",
+			),
+		},
+		"page.html.tmpl": {
+			Data: []byte(
+				"{{ with .Parent.Index }}{{ .Title }} > {{ end }}" +
+					"{{ with .Index }}{{ .Title }} > {{ end }}{{ .Current.Title }}",
+			),
+		},
+	}
+	contentFS := fstest.MapFS{
+		"index.md": pageFile(t, map[string]any{
+			"title":     "Home",
+			"createdAt": "2025-01-01T00:00:00Z",
+			"template":  "section.html.tmpl",
+		}, "Content"),
+		"blog/index.md": pageFile(t, map[string]any{
+			"title":     "Blog",
+			"createdAt": "2025-01-01T00:00:00Z",
+			"template":  "section.html.tmpl",
+		}, "Content"),
+		"blog/first.md": pageFile(t, map[string]any{
+			"title":     "First",
+			"createdAt": "2025-01-01T00:00:00Z",
+			"template":  "page.html.tmpl",
+		}, "Content"),
+		"blog/2025/index.md": pageFile(t, map[string]any{
+			"title":     "2025",
+			"createdAt": "2025-01-01T00:00:00Z",
+			"template":  "section.html.tmpl",
+		}, "Content"),
+		"blog/2025/second.md": pageFile(t, map[string]any{
+			"title":     "Second",
+			"createdAt": "2025-01-01T00:00:00Z",
+			"template":  "page.html.tmpl",
+		}, "Content"),
+		"blog/2025/third.md": pageFile(t, map[string]any{
+			"title":     "Third",
+			"createdAt": "2025-01-01T00:00:00Z",
+			"template":  "page.html.tmpl",
+		}, "Content"),
+		"blog/archive/old/old.md": pageFile(t, map[string]any{
+			"title":     "Old",
+			"createdAt": "2025-01-01T00:00:00Z",
+			"template":  "page.html.tmpl",
+		}, "Content"),
+	}
+
+	dir := t.TempDir()
+	b, err := builder.New(projectFS(t, layoutFS, contentFS), builder.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Build(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{
+			path: "index.html",
+			want: "Home\nblog: 1\nFirst\nOld\nSecond\nThird\n",
+		},
+		{
+			path: "blog/index.html",
+			want: "Home > Blog\nblog/2025: 2\nblog/archive: 0\nFirst\nOld\nSecond\nThird\n",
+		},
+		{
+			path: "blog/2025/index.html",
+			want: "Home > Blog > 2025\nSecond\nThird\n",
+		},
+		{
+			path: "blog/2025/second/index.html",
+			want: "Blog > 2025 > Second",
+		},
+	}
+
+	for _, test := range tests {
+		content, err := os.ReadFile(filepath.Join(dir, test.path))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(content) != test.want {
+			t.Fatalf("%s: got:\n%s\nwant:\n%s", test.path, content, test.want)
+		}
+	}
+}
+
+func TestTaxonomies(t *testing.T) {
+	t.Parallel()
+
+	contentFS := fstest.MapFS{
+		"a.md": pageFile(t, map[string]any{
+			"title":      "A",
+			"createdAt":  "2025-01-01T00:00:00Z",
+			"template":   "page.html.tmpl",
+			"tags":       []string{"Go", "Web"},
+			"categories": []string{"Notes"},
+		}, "Content"),
+		"posts/b.md": pageFile(t, map[string]any{
+			"title":     "B",
+			"createdAt": "2025-01-01T00:00:00Z",
+			"template":  "page.html.tmpl",
+			"tags":      []string{"go"},
+		}, "Content"),
+		"posts/c.md": pageFile(t, map[string]any{
+			"title":     "C",
+			"createdAt": "2025-01-01T00:00:00Z",
+			"template":  "page.html.tmpl",
+		}, "Content"),
+	}
+	pageTmpl := &fstest.MapFile{
+		Data: []byte(
+			"{{ range .Current.Tags }}" +
+				"{{ ($.Site.Taxonomies.tags.Term .).URL }} " +
+				"{{ end }}",
+		),
+	}
+
+	tests := []struct {
+		name      string
+		layoutFS  fstest.MapFS
+		contentFS fstest.MapFS
+		want      map[string]string
+		wantError string
+	}{
+		{
+			name: "generates pages from shared templates",
+			layoutFS: fstest.MapFS{
+				"page.html.tmpl": pageTmpl,
+				"taxonomy.html.tmpl": {
+					Data: []byte(
+						"{{ .Taxonomy.Name }}:" +
+							"{{ range .Taxonomy.Terms }} {{ .Name }} ({{ len .Pages }}){{ end }}",
+					),
+				},
+				"term.html.tmpl": {
+					Data: []byte(
+						"{{ .Taxonomy.Name }}/{{ .Term.Name }}:" +
+							"{{ range .Term.Pages }} {{ .Title }}{{ end }}",
+					),
+				},
+			},
+			contentFS: contentFS,
+			want: map[string]string{
+				"a/index.html":                "tags/go/index.html tags/web/index.html ",
+				"tags/index.html":             "tags: Go (2) Web (1)",
+				"tags/go/index.html":          "tags/Go: A B",
+				"tags/web/index.html":         "tags/Web: A",
+				"categories/index.html":       "categories: Notes (1)",
+				"categories/notes/index.html": "categories/Notes: A",
+			},
+		},
+		{
+			name: "prefers templates of the taxonomy",
+			layoutFS: fstest.MapFS{
+				"page.html.tmpl":          pageTmpl,
+				"term.html.tmpl":          {Data: []byte("shared {{ .Term.Slug }}")},
+				"tags/term.html.tmpl":     {Data: []byte("tag {{ .Term.Slug }}")},
+				"tags/taxonomy.html.tmpl": {Data: []byte("all tags")},
+			},
+			contentFS: contentFS,
+			want: map[string]string{
+				"a/index.html":                "tags/go/index.html tags/web/index.html ",
+				"tags/index.html":             "all tags",
+				"tags/go/index.html":          "tag go",
+				"tags/web/index.html":         "tag web",
+				"categories/notes/index.html": "shared notes",
+			},
+		},
+		{
+			name: "skips taxonomies without templates",
+			layoutFS: fstest.MapFS{
+				"page.html.tmpl": pageTmpl,
+			},
+			contentFS: contentFS,
+			want: map[string]string{
+				"a/index.html": "tags/go/index.html tags/web/index.html ",
+			},
+		},
+		{
+			name: "reports pages that conflict with content",
+			layoutFS: fstest.MapFS{
+				"page.html.tmpl": pageTmpl,
+				"term.html.tmpl": {Data: []byte("term")},
+			},
+			contentFS: fstest.MapFS{
+				"a.md": pageFile(t, map[string]any{
+					"title":     "A",
+					"createdAt": "2025-01-01T00:00:00Z",
+					"template":  "page.html.tmpl",
+					"tags":      []string{"Go"},
+				}, "Content"),
+				"tags/go/index.md": pageFile(t, map[string]any{
+					"title":     "Go",
+					"createdAt": "2025-01-01T00:00:00Z",
+					"template":  "page.html.tmpl",
+				}, "Content"),
+			},
+			wantError: "tags/go/index.html: tags page conflicts with tags/go/index.md",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := t.TempDir()
+			b, err := builder.New(projectFS(t, test.layoutFS, test.contentFS), builder.Options{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = b.Build(dir)
+			if test.wantError != "" {
+				if err == nil || !strings.Contains(err.Error(), test.wantError) {
+					t.Fatalf("expected error %q, got %v", test.wantError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := map[string]string{}
+			for _, p := range testutil.SortedPaths(t, os.DirFS(dir)) {
+				if strings.HasPrefix(p, "posts/") {
+					continue
+				}
+				content, err := os.ReadFile(filepath.Join(dir, p))
+				if err != nil {
+					t.Fatal(err)
+				}
+				got[p] = string(content)
+			}
+			if !maps.Equal(got, test.want) {
+				t.Fatalf("expected %v, got %v", test.want, got)
+			}
+		})
+	}
+}
+
+func TestPagination(t *testing.T) {
+	t.Parallel()
+
+	pfs := projectFS(
+		t,
+		fstest.MapFS{
+			"page.html.tmpl": {
+				Data: []byte(
+					"{{ .Current.Title }}" +
+						"{{ with .Paginator }} {{ .Number }}/{{ len .Pagers }}:" +
+						"{{ range .Pages }} {{ .Title }}{{ end }}" +
+						" prev={{ .PrevURL }} next={{ .NextURL }}{{ end }}",
+				),
+			},
+		},
+		fstest.MapFS{
+			"blog/_section.json": {Data: []byte(`{"paginate": 2}`)},
+			"blog/index.md": pageFile(t, map[string]any{
+				"title":     "Blog",
+				"createdAt": "2025-01-01T00:00:00Z",
+				"template":  "page.html.tmpl",
+			}, "Content"),
+			"blog/a.md": pageFile(t, map[string]any{
+				"title":     "A",
+				"createdAt": "2025-01-01T00:00:00Z",
+				"template":  "page.html.tmpl",
+			}, "Content"),
+			"blog/b.md": pageFile(t, map[string]any{
+				"title":     "B",
+				"createdAt": "2025-01-02T00:00:00Z",
+				"template":  "page.html.tmpl",
+			}, "Content"),
+			"blog/c.md": pageFile(t, map[string]any{
+				"title":     "C",
+				"createdAt": "2025-01-03T00:00:00Z",
+				"template":  "page.html.tmpl",
+			}, "Content"),
+			"blog/d.md": pageFile(t, map[string]any{
+				"title":     "D",
+				"createdAt": "2025-01-04T00:00:00Z",
+				"template":  "page.html.tmpl",
+			}, "Content"),
+			"blog/e.md": pageFile(t, map[string]any{
+				"title":     "E",
+				"createdAt": "2025-01-05T00:00:00Z",
+				"template":  "page.html.tmpl",
+			}, "Content"),
+		},
+	).(fstest.MapFS)
+
+	dir := t.TempDir()
+	b, err := builder.New(pfs, builder.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Build(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"blog/index.html":        "Blog 1/3: E D prev= next=blog/page/2/index.html",
+		"blog/page/2/index.html": "Blog 2/3: C B prev=blog/index.html next=blog/page/3/index.html",
+		"blog/page/3/index.html": "Blog 3/3: A prev=blog/page/2/index.html next=",
+		"blog/a/index.html":      "A",
+	}
+	for p, want := range want {
+		content, err := os.ReadFile(filepath.Join(dir, p))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(content) != want {
+			t.Fatalf("%s: expected %q, got %q", p, want, content)
+		}
+	}
+
+	delete(pfs, path.Join(builder.ContentDir, "blog/a.md"))
+	if err := b.Build(dir); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "blog/page/3/index.html")); !os.IsNotExist(err) {
+		t.Fatalf("expected blog/page/3/index.html to be removed, got %v", err)
+	}
+
+	pfs[path.Join(builder.ContentDir, "blog/page/2.md")] = pageFile(t, map[string]any{
+		"title":     "Page 2",
+		"createdAt": "2025-01-01T00:00:00Z",
+		"template":  "page.html.tmpl",
+	}, "Content")
+	err = b.Build(dir)
+	wantError := "blog/page/2/index.html: page 2 of blog/index.md conflicts with blog/page/2.md"
+	if err == nil || !strings.Contains(err.Error(), wantError) {
+		t.Fatalf("expected error %q, got %v", wantError, err)
+	}
+}
+
+func TestTemplateFuncs(t *testing.T) {
+	t.Parallel()
+
+	pfs := projectFS(
+		t,
+		fstest.MapFS{
+			"page.html.tmpl": {
+				Data: []byte(
+					`{{ formatDate "2006-01-02" .Current.UpdatedAt }}|` +
+						`{{ absURL .Current.URL }}|` +
+						`{{ relURL .Current.URL }}|` +
+						`{{ markdownify .Current.Title }}`,
+				),
+			},
+		},
+		fstest.MapFS{
+			"page.md": pageFile(t, map[string]any{
+				"title":     "A *fine* page",
+				"createdAt": "2025-05-13T00:00:00Z",
+				"template":  "page.html.tmpl",
+			}, "Content"),
+		},
+	).(fstest.MapFS)
+	pfs["satisficer.json"] = &fstest.MapFile{
+		Data: []byte(`{"baseURL": "https://example.com/site"}`),
+	}
+
+	dir := t.TempDir()
+	b, err := builder.New(pfs, builder.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Build(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, "page/index.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "|https://example.com/site/page/index.html|/site/page/index.html|" +
+		"<p>A <em>fine</em> page</p>\n"
+	if string(content) != want {
+		t.Fatalf("expected %q, got %q", want, content)
+	}
+}
+
+func TestEscaping(t *testing.T) {
+	t.Parallel()
+
+	pfs := projectFS(
+		t,
+		fstest.MapFS{
+			"page.html.tmpl": {
+				Data: []byte(
+					`<a title="{{ .Current.Title }}">{{ .Current.Title }}</a>` +
+						`{{ .Current.Content }}`,
+				),
+			},
+			"feed.xml.tmpl": {
+				Data: []byte(
+					`{{ range .Site.Pages }}<title>{{ .Title }}</title>{{ end }}`,
+				),
+			},
+		},
+		fstest.MapFS{
+			"page.md": pageFile(t, map[string]any{
+				"title":     `Tom & "Jerry" <3`,
+				"createdAt": "2025-05-13T00:00:00Z",
+				"template":  "page.html.tmpl",
+			}, "Bold & *em*"),
+			"feed.md": pageFile(t, map[string]any{
+				"title":     "Feed",
+				"createdAt": "2025-05-13T00:00:00Z",
+				"template":  "feed.xml.tmpl",
+			}, ""),
+		},
+	).(fstest.MapFS)
+	pfs["satisficer.json"] = &fstest.MapFile{
+		Data: []byte(`{"baseURL": "https://example.com", "sitemap": true}`),
+	}
+
+	dir := t.TempDir()
+	b, err := builder.New(pfs, builder.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Build(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{
+			path: "page/index.html",
+			want: `<a title="Tom &amp; &#34;Jerry&#34; &lt;3">Tom &amp; &#34;Jerry&#34; &lt;3</a>` +
+				"<p>Bold &amp; <em>em</em></p>\n",
+		},
+		{
+			path: "feed.xml",
+			want: `<title>Feed</title><title>Tom & "Jerry" <3</title>`,
+		},
+	}
+	for _, test := range tests {
+		content, err := os.ReadFile(filepath.Join(dir, test.path))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(content) != test.want {
+			t.Fatalf("%s: expected %q, got %q", test.path, test.want, content)
+		}
+	}
+
+	sitemap, err := os.ReadFile(filepath.Join(dir, builder.SitemapFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(sitemap), "feed.xml") {
+		t.Fatalf("expected sitemap to leave out feed.xml, got %s", sitemap)
+	}
+}
+
+func TestTemplateResolution(t *testing.T) {
+	t.Parallel()
+
+	pfs := projectFS(
+		t,
+		fstest.MapFS{
+			"custom.html.tmpl":         {Data: []byte("custom")},
+			"docs.html.tmpl":           {Data: []byte("docs")},
+			"posts/page.html.tmpl":     {Data: []byte("posts")},
+			"_default/page.html.tmpl":  {Data: []byte("default")},
+			"_default/robots.txt.tmpl": {Data: []byte("robots")},
+			"tutorials/page.html.tmpl": {Data: []byte("tutorials")},
+		},
+		fstest.MapFS{
+			"about.md": pageFile(t, map[string]any{
+				"title":     "Page",
+				"createdAt": "2025-05-13T00:00:00Z",
+			}, "# Page"),
+			"robots.md": pageFile(t, map[string]any{
+				"title":     "Page",
+				"createdAt": "2025-05-13T00:00:00Z",
+				"template":  "_default/robots.txt.tmpl",
+			}, "# Page"),
+			"posts/first.md": pageFile(t, map[string]any{
+				"title":     "Page",
+				"createdAt": "2025-05-13T00:00:00Z",
+			}, "# Page"),
+			"posts/custom.md": pageFile(t, map[string]any{
+				"title":     "Page",
+				"createdAt": "2025-05-13T00:00:00Z",
+				"template":  "custom.html.tmpl",
+			}, "# Page"),
+			"posts/nested/second.md": pageFile(t, map[string]any{
+				"title":     "Page",
+				"createdAt": "2025-05-13T00:00:00Z",
+			}, "# Page"),
+			"docs/intro.md": pageFile(t, map[string]any{
+				"title":     "Page",
+				"createdAt": "2025-05-13T00:00:00Z",
+			}, "# Page"),
+			"docs/_section.json": {Data: []byte(`{"template": "docs.html.tmpl"}`)},
+			"docs/guides/2024/a.md": pageFile(t, map[string]any{
+				"title":     "Page",
+				"createdAt": "2025-05-13T00:00:00Z",
+			}, "# Page"),
+			"tutorials/intro.md": pageFile(t, map[string]any{
+				"title":     "Page",
+				"createdAt": "2025-05-13T00:00:00Z",
+			}, "# Page"),
+			"tutorials/_section.json": {Data: []byte(`{"template": "docs.html.tmpl"}`)},
+			"tutorials/advanced/a.md": pageFile(t, map[string]any{
+				"title":     "Page",
+				"createdAt": "2025-05-13T00:00:00Z",
+			}, "# Page"),
+		},
+	)
+
+	dir := t.TempDir()
+	b, err := builder.New(pfs, builder.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Build(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"about/index.html":                "default",
+		"robots.txt":                      "robots",
+		"posts/first/index.html":          "posts",
+		"posts/custom/index.html":         "custom",
+		"posts/nested/second/index.html":  "posts",
+		"docs/intro/index.html":           "docs",
+		"docs/guides/2024/a/index.html":   "docs",
+		"tutorials/intro/index.html":      "tutorials",
+		"tutorials/advanced/a/index.html": "tutorials",
+	}
+	for path, want := range want {
+		content, err := os.ReadFile(filepath.Join(dir, path))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(content) != want {
+			t.Fatalf("%s: expected %q, got %q", path, want, content)
+		}
+	}
+}
+
+func TestData(t *testing.T) {
+	t.Parallel()
+
+	pfs := projectFS(
+		t,
+		fstest.MapFS{
+			"page.html.tmpl": {
+				Data: []byte(
+					"{{ range .Site.Data.team }}{{ .name }} ({{ .role }})\n{{ end }}" +
+						"{{ range .Site.Data.nav.links }}{{ .title }}: {{ .url }}\n{{ end }}",
+				),
+			},
+		},
+		fstest.MapFS{
+			"index.md": pageFile(t, map[string]any{
+				"title":     "Home",
+				"createdAt": "2025-05-13T00:00:00Z",
+				"template":  "page.html.tmpl",
+			}, "Content"),
+		},
+	).(fstest.MapFS)
+	pfs["data/team.json"] = &fstest.MapFile{
+		Data: []byte(`[{"name": "Jane", "role": "Editor"}, {"name": "Joe", "role": "Writer"}]`),
+	}
+	pfs["data/nav/links.csv"] = &fstest.MapFile{
+		Data: []byte("title,url\nHome,/\nAbout,/about/\n"),
+	}
+
+	dir := t.TempDir()
+	b, err := builder.New(pfs, builder.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Build(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, "index.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Jane (Editor)\nJoe (Writer)\nHome: /\nAbout: /about/\n"
+	if string(content) != want {
+		t.Fatalf("expected %q, got %q", want, content)
+	}
+
+	pfs["data/team.json"] = &fstest.MapFile{Data: []byte(`[`)}
+	var buildErr *builder.BuildError
+	if err := b.Build(dir); !errors.As(err, &buildErr) {
+		t.Fatalf("expected a BuildError, got %v", err)
+	}
+	if want := "team.json: unexpected EOF"; buildErr.Error() != want {
+		t.Fatalf("expected error %q, got %q", want, buildErr.Error())
+	}
+}
+
+func TestConfig(t *testing.T) {
+	t.Parallel()
+
+	pfs := projectFS(
+		t,
+		fstest.MapFS{
+			"base.html.tmpl": {
+				Data: []byte(
+					"{{ .Site.Params.author }}: {{ .Current.URL }} {{ .Current.Content }}",
+				),
+			},
+			"docs/page.html.tmpl": {Data: []byte("docs: {{ .Current.URL }}")},
+		},
+		fstest.MapFS{
+			"index.md": pageFile(t, map[string]any{
+				"title":     "Home",
+				"createdAt": "2025-05-13T00:00:00Z",
+			}, "<b>Hello</b>\nworld"),
+			"about.md": pageFile(t, map[string]any{
+				"title":     "About",
+				"createdAt": "2025-05-13T00:00:00Z",
+			}, "<b>Hello</b>\nworld"),
+			"docs/intro.md": pageFile(t, map[string]any{
+				"title":     "Intro",
+				"createdAt": "2025-05-13T00:00:00Z",
+			}, "<b>Hello</b>\nworld"),
+		},
+	).(fstest.MapFS)
+	pfs["satisficer.json"] = &fstest.MapFile{
+		Data: []byte(`{
+			"defaultTemplate": "base.html.tmpl",
+			"markdown": {"unsafe": true, "hardWraps": true},
+			"output": {"uglyURLs": true},
+			"server": {"port": 8080},
+			"params": {"author": "Jane & Joe"}
+		}`),
+	}
+
+	dir := t.TempDir()
+	b, err := builder.New(pfs, builder.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Port() != 8080 {
+		t.Fatalf("expected port 8080, got %d", b.Port())
+	}
+	if err := b.Build(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"index.html":      "Jane &amp; Joe: index.html <p><b>Hello</b><br>\nworld</p>\n",
+		"about.html":      "Jane &amp; Joe: about.html <p><b>Hello</b><br>\nworld</p>\n",
+		"docs/intro.html": "docs: docs/intro.html",
+	}
+	for path, want := range want {
+		content, err := os.ReadFile(filepath.Join(dir, path))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(content) != want {
+			t.Fatalf("%s: expected %q, got %q", path, want, content)
+		}
+	}
+}
+
+func TestConfig_Invalid(t *testing.T) {
+	t.Parallel()
+
+	pfs := fstest.MapFS{
+		"satisficer.json": {Data: []byte(`{"server": {"port": 70000}}`)},
+	}
+	_, err := builder.New(pfs, builder.Options{})
+	want := "satisficer.json: server.port must be between 1 and 65535, got 70000"
+	if err == nil || err.Error() != want {
+		t.Fatalf("expected error %q, got %v", want, err)
+	}
+
+	pfs["satisficer.json"] = &fstest.MapFile{Data: []byte(`{}`)}
+	b, err := builder.New(pfs, builder.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Port() != 3000 {
+		t.Fatalf("expected the default port, got %d", b.Port())
+	}
+}
+
+func TestLinks(t *testing.T) {
+	t.Parallel()
+
+	pfs := projectFS(
+		t,
+		fstest.MapFS{
+			"_default/page.html.tmpl": {
+				Data: []byte("{{ .Current.Summary }}|{{ .Current.Content }}"),
+			},
+		},
+		fstest.MapFS{
+			"index.md": pageFile(t, map[string]any{
+				"title":     "Page",
+				"createdAt": "2025-05-13T00:00:00Z",
+			}, "Home"),
+			"docs/intro.md": pageFile(
+				t,
+				map[string]any{"title": "Page", "createdAt": "2025-05-13T00:00:00Z"},
+				"[Setup](../setup.md#install-go) and [home](../index.md)\n\n<!--more-->\n\n"+
+					"[Intro](intro.md), [site](https://example.com/a.md) and [raw](/setup.md)",
+			),
+			"setup.md": pageFile(t, map[string]any{
+				"title":     "Page",
+				"createdAt": "2025-05-13T00:00:00Z",
+				"uglyURL":   true,
+			}, "Setup"),
+		},
+	).(fstest.MapFS)
+	pfs["satisficer.json"] = &fstest.MapFile{
+		Data: []byte(`{"baseURL": "https://example.com/site/"}`),
+	}
+
+	dir := t.TempDir()
+	b, err := builder.New(pfs, builder.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Build(dir); err != nil {
+		t.Fatal(err)
+	}
+	summary := `<p><a href="/site/setup.html#install-go">Setup</a> and ` +
+		`<a href="/site/">home</a></p>` + "\n"
+	want := summary + "|" + summary + `<p><a href="/site/docs/intro/">Intro</a>, ` +
+		`<a href="https://example.com/a.md" target="_blank" rel="noopener noreferrer">site</a> ` +
+		`and <a href="/setup.md">raw</a></p>` + "\n"
+	content, err := os.ReadFile(filepath.Join(dir, "docs/intro/index.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != want {
+		t.Fatalf("expected %q, got %q", want, content)
+	}
+
+	// Pages are rendered again when the URL of a page they link to changes.
+	pfs["content/setup.md"] = pageFile(t, map[string]any{
+		"title":     "Page",
+		"createdAt": "2025-05-13T00:00:00Z",
+	}, "Setup")
+	if err := b.Build(dir); err != nil {
+		t.Fatal(err)
+	}
+	content, err = os.ReadFile(filepath.Join(dir, "docs/intro/index.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `href="/site/setup/#install-go"`; !strings.Contains(string(content), want) {
+		t.Fatalf("expected %q in %q", want, content)
+	}
+}
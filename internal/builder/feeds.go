@@ -0,0 +1,116 @@
+package builder
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/fivethirty/satisficer/internal/builder/internal/config"
+	"github.com/fivethirty/satisficer/internal/builder/internal/feeds"
+	"github.com/fivethirty/satisficer/internal/builder/internal/manifest"
+	"github.com/fivethirty/satisficer/internal/builder/internal/sections"
+)
+
+const (
+	RSSFile  = "index.xml"
+	AtomFile = "atom.xml"
+)
+
+// addFeeds adds the feeds enabled in the config of the section in dir. Feeds
+// list the section's pages, other than its index page, newest first.
+func (b *Builder) addFeeds(
+	dir string,
+	s *sections.Section,
+	cfg *config.Config,
+	sectionHash string,
+	bd *build,
+) {
+	fc := s.Config.Feeds
+	if !fc.Enabled() {
+		return
+	}
+	if cfg.BaseURL == "" {
+		bd.errs = append(bd.errs, fmt.Errorf(
+			"%s: feeds require a baseURL in %s",
+			path.Join(dir, config.SectionFile),
+			config.File,
+		))
+		return
+	}
+
+	limit := fc.Limit
+	if limit == 0 {
+		limit = config.DefaultFeedLimit
+	}
+
+	title := cfg.Title
+	if s.Index != nil {
+		title = s.Index.Title
+	}
+	if title == "" {
+		title = dir
+	}
+	pages := s.Pages.ByCreatedAt().Reverse()
+	pages = pages[:min(limit, len(pages))]
+
+	entries := make([]feeds.Entry, 0, len(pages))
+	for _, page := range pages {
+		entries = append(entries, feeds.Entry{
+			Title:     page.Title,
+			Link:      permalink(cfg.BaseURL, page.URL),
+			Published: page.CreatedAt,
+			Updated:   page.UpdatedAt,
+			Content:   string(page.Content),
+		})
+	}
+
+	formats := []struct {
+		enabled bool
+		file    string
+		write   func(io.Writer, *feeds.Feed) error
+	}{
+		{enabled: fc.RSS, file: RSSFile, write: feeds.WriteRSS},
+		{enabled: fc.Atom, file: AtomFile, write: feeds.WriteAtom},
+	}
+	for _, format := range formats {
+		if !format.enabled {
+			continue
+		}
+		out := path.Join(dir, format.file)
+		feed := &feeds.Feed{
+			Title:    title,
+			Link:     permalink(cfg.BaseURL, dir+"/"),
+			FeedLink: permalink(cfg.BaseURL, out),
+			Entries:  entries,
+		}
+		bd.add(output{
+			path: out,
+			hash: manifest.Hash(
+				version,
+				"feed",
+				out,
+				sectionHash,
+				cfg.Title,
+				cfg.BaseURL,
+				strconv.Itoa(limit),
+			),
+			write: func(dest string) error {
+				slog.Info("Generating feed", "path", out)
+				return writeOutput(dest, func(w io.Writer) error {
+					return format.write(w, feed)
+				})
+			},
+		})
+	}
+}
+
+// permalink returns the absolute URL of p, a path in the build directory.
+// Directory index files are linked to by their directory.
+func permalink(baseURL string, p string) string {
+	p = strings.TrimSuffix(p, "index.html")
+	p = strings.TrimPrefix(p, "./")
+	return baseURL + "/" + p
+}
@@ -0,0 +1,213 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"math"
+	"net/url"
+	"strings"
+)
+
+const (
+	File        = "satisficer.json"
+	SectionFile = "_section.json"
+)
+
+// Config is the project wide configuration read from File at the root of a
+// project. Every field is optional.
+type Config struct {
+	Title   string `json:"title"`
+	BaseURL string `json:"baseURL"`
+	// Sitemap generates a sitemap.xml listing every page. It requires
+	// BaseURL.
+	Sitemap bool `json:"sitemap"`
+	// DefaultTemplate is the template of pages that name none in their front
+	// matter or section config and have no layout/<dir>/page.html.tmpl. When
+	// empty, layout/_default/page.html.tmpl is used.
+	DefaultTemplate string   `json:"defaultTemplate"`
+	Markdown        Markdown `json:"markdown"`
+	Output          Output   `json:"output"`
+	Server          Server   `json:"server"`
+	// Params holds arbitrary values for templates, such as
+	// {{ .Site.Params.author }}.
+	Params map[string]any `json:"params"`
+}
+
+type Markdown struct {
+	// Unsafe renders raw HTML in markdown rather than leaving it out.
+	Unsafe     bool       `json:"unsafe"`
+	HardWraps  bool       `json:"hardWraps"`
+	Extensions Extensions `json:"extensions"`
+	Highlight  Highlight  `json:"highlight"`
+	TOC        TOC        `json:"toc"`
+	// SummaryLength is the number of words of summaries cut from the text of
+	// pages. When zero, markdown.DefaultSummaryLength is used.
+	SummaryLength int `json:"summaryLength"`
+}
+
+// Extensions enable markdown syntax beyond CommonMark. It mirrors
+// markdown.Extensions.
+type Extensions struct {
+	Tables          bool `json:"tables"`
+	Strikethrough   bool `json:"strikethrough"`
+	TaskLists       bool `json:"taskLists"`
+	Autolinks       bool `json:"autolinks"`
+	Footnotes       bool `json:"footnotes"`
+	DefinitionLists bool `json:"definitionLists"`
+	Typographer     bool `json:"typographer"`
+}
+
+// Highlight configures syntax highlighting of fenced code blocks. It mirrors
+// markdown.Highlight.
+type Highlight struct {
+	Enabled     bool `json:"enabled"`
+	LineNumbers bool `json:"lineNumbers"`
+}
+
+// TOC configures the levels of the headings listed in the table of contents
+// of pages. It mirrors markdown.TOC.
+type TOC struct {
+	MinLevel int `json:"minLevel"`
+	MaxLevel int `json:"maxLevel"`
+}
+
+type Output struct {
+	// UglyURLs renders every page to <name>.html rather than
+	// <name>/index.html, as if its front matter set uglyURL.
+	UglyURLs bool `json:"uglyURLs"`
+}
+
+// Server holds settings of the development server.
+type Server struct {
+	// Port is used unless another one is passed on the command line. When
+	// zero, DefaultPort is used.
+	Port int `json:"port"`
+}
+
+const DefaultPort = 3000
+
+// Section is the configuration of a single content directory read from
+// SectionFile in that directory.
+type Section struct {
+	Feeds Feeds `json:"feeds"`
+	// Paginate is the number of pages listed on each page of the section's
+	// index. When zero, the index is not paginated.
+	Paginate int `json:"paginate"`
+	// Template is the template of pages in the section that do not name one
+	// in their front matter and have no layout/<dir>/page.html.tmpl.
+	Template string `json:"template"`
+}
+
+type Feeds struct {
+	RSS  bool `json:"rss"`
+	Atom bool `json:"atom"`
+	// Limit is the maximum number of pages in a feed. When zero,
+	// DefaultFeedLimit is used.
+	Limit int `json:"limit"`
+}
+
+const DefaultFeedLimit = 20
+
+func (f Feeds) Enabled() bool {
+	return f.RSS || f.Atom
+}
+
+// FromFS reads File from fsys. A project without a config file gets the zero
+// Config.
+func FromFS(fsys fs.FS) (*Config, error) {
+	file, err := fsys.Open(File)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &Config{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+
+	c := &Config{}
+	if err := decode(file, c); err != nil {
+		return nil, fmt.Errorf("%s: %w", File, err)
+	}
+	if err := c.validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", File, err)
+	}
+	c.BaseURL = strings.TrimSuffix(c.BaseURL, "/")
+	return c, nil
+}
+
+func (c *Config) validate() error {
+	if c.Server.Port < 0 || c.Server.Port > math.MaxUint16 {
+		return fmt.Errorf(
+			"server.port must be between 1 and %d, got %d",
+			math.MaxUint16,
+			c.Server.Port,
+		)
+	}
+	if err := c.Markdown.TOC.validate(); err != nil {
+		return err
+	}
+	if c.Markdown.SummaryLength < 0 {
+		return fmt.Errorf(
+			"markdown.summaryLength must not be negative, got %d",
+			c.Markdown.SummaryLength,
+		)
+	}
+	if c.BaseURL == "" {
+		if c.Sitemap {
+			return errors.New("sitemap requires a baseURL")
+		}
+		return nil
+	}
+	u, err := url.Parse(c.BaseURL)
+	if err != nil {
+		return fmt.Errorf("invalid baseURL: %w", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("baseURL must be an absolute http or https URL, got %q", c.BaseURL)
+	}
+	return nil
+}
+
+func (t TOC) validate() error {
+	levels := []struct {
+		name  string
+		level int
+	}{{"minLevel", t.MinLevel}, {"maxLevel", t.MaxLevel}}
+	for _, l := range levels {
+		if l.level < 0 || l.level > 6 {
+			return fmt.Errorf("markdown.toc.%s must be between 1 and 6, got %d", l.name, l.level)
+		}
+	}
+	if t.MinLevel != 0 && t.MaxLevel != 0 && t.MinLevel > t.MaxLevel {
+		return fmt.Errorf(
+			"markdown.toc.minLevel must not be greater than maxLevel, got %d and %d",
+			t.MinLevel,
+			t.MaxLevel,
+		)
+	}
+	return nil
+}
+
+// ParseSection reads a SectionFile.
+func ParseSection(r io.Reader) (*Section, error) {
+	s := &Section{}
+	if err := decode(r, s); err != nil {
+		return nil, err
+	}
+	if s.Feeds.Limit < 0 {
+		return nil, fmt.Errorf("feeds.limit must not be negative, got %d", s.Feeds.Limit)
+	}
+	if s.Paginate < 0 {
+		return nil, fmt.Errorf("paginate must not be negative, got %d", s.Paginate)
+	}
+	return s, nil
+}
+
+func decode(r io.Reader, v any) error {
+	decoder := json.NewDecoder(r)
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(v)
+}
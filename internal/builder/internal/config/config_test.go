@@ -0,0 +1,252 @@
+package config_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/fivethirty/satisficer/internal/builder/internal/config"
+)
+
+func TestFromFS(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		fs         fstest.MapFS
+		wantConfig *config.Config
+		wantError  bool
+	}{
+		{
+			name:       "missing config file",
+			fs:         fstest.MapFS{},
+			wantConfig: &config.Config{},
+		},
+		{
+			name: "full config file",
+			fs: fstest.MapFS{
+				config.File: {
+					Data: []byte(`{"title": "My Site", "baseURL": "https://example.com/"}`),
+				},
+			},
+			wantConfig: &config.Config{
+				Title:   "My Site",
+				BaseURL: "https://example.com",
+			},
+		},
+		{
+			name: "page, markdown, output and server settings",
+			fs: fstest.MapFS{
+				config.File: {
+					Data: []byte(`{
+						"defaultTemplate": "base.html.tmpl",
+						"markdown": {
+							"unsafe": true,
+							"hardWraps": true,
+							"extensions": {"tables": true, "footnotes": true},
+							"highlight": {"enabled": true, "lineNumbers": true},
+							"toc": {"minLevel": 2, "maxLevel": 3},
+							"summaryLength": 30
+						},
+						"output": {"uglyURLs": true},
+						"server": {"port": 8080},
+						"params": {"author": "Jane"}
+					}`),
+				},
+			},
+			wantConfig: &config.Config{
+				DefaultTemplate: "base.html.tmpl",
+				Markdown: config.Markdown{
+					Unsafe:        true,
+					HardWraps:     true,
+					Extensions:    config.Extensions{Tables: true, Footnotes: true},
+					Highlight:     config.Highlight{Enabled: true, LineNumbers: true},
+					TOC:           config.TOC{MinLevel: 2, MaxLevel: 3},
+					SummaryLength: 30,
+				},
+				Output: config.Output{UglyURLs: true},
+				Server: config.Server{Port: 8080},
+				Params: map[string]any{"author": "Jane"},
+			},
+		},
+		{
+			name: "toc level out of range",
+			fs: fstest.MapFS{
+				config.File: {Data: []byte(`{"markdown": {"toc": {"maxLevel": 7}}}`)},
+			},
+			wantError: true,
+		},
+		{
+			name: "toc min level greater than max level",
+			fs: fstest.MapFS{
+				config.File: {
+					Data: []byte(`{"markdown": {"toc": {"minLevel": 3, "maxLevel": 2}}}`),
+				},
+			},
+			wantError: true,
+		},
+		{
+			name: "negative summary length",
+			fs: fstest.MapFS{
+				config.File: {Data: []byte(`{"markdown": {"summaryLength": -1}}`)},
+			},
+			wantError: true,
+		},
+		{
+			name: "port out of range",
+			fs: fstest.MapFS{
+				config.File: {Data: []byte(`{"server": {"port": 65536}}`)},
+			},
+			wantError: true,
+		},
+		{
+			name: "unknown extension",
+			fs: fstest.MapFS{
+				config.File: {Data: []byte(`{"markdown": {"extensions": {"emoji": true}}}`)},
+			},
+			wantError: true,
+		},
+		{
+			name: "unknown nested field",
+			fs: fstest.MapFS{
+				config.File: {Data: []byte(`{"markdown": {"unsafeHTML": true}}`)},
+			},
+			wantError: true,
+		},
+		{
+			name: "sitemap with base url",
+			fs: fstest.MapFS{
+				config.File: {
+					Data: []byte(`{"baseURL": "https://example.com", "sitemap": true}`),
+				},
+			},
+			wantConfig: &config.Config{
+				BaseURL: "https://example.com",
+				Sitemap: true,
+			},
+		},
+		{
+			name: "sitemap without base url",
+			fs: fstest.MapFS{
+				config.File: {Data: []byte(`{"sitemap": true}`)},
+			},
+			wantError: true,
+		},
+		{
+			name: "invalid json",
+			fs: fstest.MapFS{
+				config.File: {Data: []byte(`{"title":`)},
+			},
+			wantError: true,
+		},
+		{
+			name: "unknown field",
+			fs: fstest.MapFS{
+				config.File: {Data: []byte(`{"titel": "My Site"}`)},
+			},
+			wantError: true,
+		},
+		{
+			name: "relative base url",
+			fs: fstest.MapFS{
+				config.File: {Data: []byte(`{"baseURL": "/blog"}`)},
+			},
+			wantError: true,
+		},
+		{
+			name: "base url with unsupported scheme",
+			fs: fstest.MapFS{
+				config.File: {Data: []byte(`{"baseURL": "ftp://example.com"}`)},
+			},
+			wantError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			c, err := config.FromFS(test.fs)
+			if err != nil {
+				if !test.wantError {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				if !strings.HasPrefix(err.Error(), config.File+": ") {
+					t.Fatalf("expected error to name %s, got %v", config.File, err)
+				}
+				return
+			}
+			if test.wantError {
+				t.Fatal("expected error, got nil")
+			}
+			if !reflect.DeepEqual(c, test.wantConfig) {
+				t.Fatalf("expected config %+v, got %+v", test.wantConfig, c)
+			}
+		})
+	}
+}
+
+func TestParseSection(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		data        string
+		wantSection *config.Section
+		wantError   bool
+	}{
+		{
+			name:        "empty section config",
+			data:        `{}`,
+			wantSection: &config.Section{},
+		},
+		{
+			name: "feeds",
+			data: `{"feeds": {"rss": true, "atom": true, "limit": 5}}`,
+			wantSection: &config.Section{
+				Feeds: config.Feeds{RSS: true, Atom: true, Limit: 5},
+			},
+		},
+		{
+			name:        "paginate",
+			data:        `{"paginate": 10}`,
+			wantSection: &config.Section{Paginate: 10},
+		},
+		{
+			name:      "negative paginate",
+			data:      `{"paginate": -1}`,
+			wantError: true,
+		},
+		{
+			name:      "negative feed limit",
+			data:      `{"feeds": {"rss": true, "limit": -1}}`,
+			wantError: true,
+		},
+		{
+			name:      "unknown field",
+			data:      `{"feed": {"rss": true}}`,
+			wantError: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			s, err := config.ParseSection(strings.NewReader(test.data))
+			if err != nil {
+				if !test.wantError {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if test.wantError {
+				t.Fatal("expected error, got nil")
+			}
+			if !reflect.DeepEqual(s, test.wantSection) {
+				t.Fatalf("expected section %+v, got %+v", test.wantSection, s)
+			}
+		})
+	}
+}
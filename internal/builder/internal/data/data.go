@@ -0,0 +1,134 @@
+package data
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"path"
+	"strings"
+)
+
+// FromFS loads every JSON and CSV file in fsys. Each file is keyed by its name
+// without the extension, within maps keyed by the names of the directories it
+// is in, so that data/team.json is at "team" and data/docs/links.csv is at
+// "docs" then "links". JSON files hold any JSON value. CSV files start with a
+// header row and are loaded as a list with a map for each other row, keyed by
+// the header. Other files are skipped.
+//
+// If any file can't be loaded, the returned error joins the errors of every
+// such file, each prefixed with the file's path.
+func FromFS(fsys fs.FS) (map[string]any, error) {
+	data := map[string]any{}
+	// files maps the key of each loaded file to its path.
+	files := map[string]string{}
+	errs := []error{}
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if p == "." && errors.Is(err, fs.ErrNotExist) {
+			return fs.SkipDir
+		}
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		read, ok := readers[path.Ext(p)]
+		if !ok {
+			slog.Info("Skipping data file", "path", p)
+			return nil
+		}
+		slog.Info("Loading data file", "path", p)
+		value, err := readFile(fsys, p, read)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", p, err))
+			return nil
+		}
+
+		key := strings.TrimSuffix(p, path.Ext(p))
+		for other, otherPath := range files {
+			if other == key || strings.HasPrefix(other, key+"/") ||
+				strings.HasPrefix(key, other+"/") {
+				errs = append(errs, fmt.Errorf("%s: conflicts with %s", p, otherPath))
+				return nil
+			}
+		}
+		files[key] = p
+		set(data, strings.Split(key, "/"), value)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load data: %w", err)
+	}
+	return data, errors.Join(errs...)
+}
+
+var readers = map[string]func(io.Reader) (any, error){
+	".json": readJSON,
+	".csv":  readCSV,
+}
+
+func set(data map[string]any, keys []string, value any) {
+	for _, key := range keys[:len(keys)-1] {
+		dir, ok := data[key].(map[string]any)
+		if !ok {
+			dir = map[string]any{}
+			data[key] = dir
+		}
+		data = dir
+	}
+	data[keys[len(keys)-1]] = value
+}
+
+func readFile(fsys fs.FS, p string, read func(io.Reader) (any, error)) (any, error) {
+	file, err := fsys.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+	return read(file)
+}
+
+func readJSON(r io.Reader) (any, error) {
+	var value any
+	decoder := json.NewDecoder(r)
+	if err := decoder.Decode(&value); err != nil {
+		return nil, err
+	}
+	if _, err := decoder.Token(); !errors.Is(err, io.EOF) {
+		return nil, errors.New("unexpected data after JSON value")
+	}
+	return value, nil
+}
+
+func readCSV(r io.Reader) (any, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	rows := []map[string]string{}
+	if len(records) == 0 {
+		return rows, nil
+	}
+
+	header := records[0]
+	seen := make(map[string]bool, len(header))
+	for _, name := range header {
+		if seen[name] {
+			return nil, fmt.Errorf("duplicate column %q", name)
+		}
+		seen[name] = true
+	}
+	for _, record := range records[1:] {
+		row := make(map[string]string, len(header))
+		for i, name := range header {
+			row[name] = record[i]
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
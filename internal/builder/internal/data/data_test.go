@@ -0,0 +1,113 @@
+package data_test
+
+import (
+	"io/fs"
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	"github.com/fivethirty/satisficer/internal/builder/internal/data"
+)
+
+func TestFromFS(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		fsys      fstest.MapFS
+		want      map[string]any
+		wantError string
+	}{
+		{
+			name: "loads nothing from an empty directory",
+			fsys: fstest.MapFS{},
+			want: map[string]any{},
+		},
+		{
+			name: "loads JSON and CSV files",
+			fsys: fstest.MapFS{
+				"team.json": {Data: []byte(`[{"name": "Jane", "age": 30}]`)},
+				"links.csv": {Data: []byte("title,url\nHome,/\n\"A, B\",/ab\n")},
+				"empty.csv": {Data: []byte("")},
+				"notes.txt": {Data: []byte("skipped")},
+				"docs/nav/main.json": {
+					Data: []byte(`{"items": ["a", "b"]}`),
+				},
+			},
+			want: map[string]any{
+				"team": []any{map[string]any{"name": "Jane", "age": float64(30)}},
+				"links": []map[string]string{
+					{"title": "Home", "url": "/"},
+					{"title": "A, B", "url": "/ab"},
+				},
+				"empty": []map[string]string{},
+				"docs": map[string]any{
+					"nav": map[string]any{
+						"main": map[string]any{"items": []any{"a", "b"}},
+					},
+				},
+			},
+		},
+		{
+			name: "reports every invalid file",
+			fsys: fstest.MapFS{
+				"a.json":  {Data: []byte(`{"a": `)},
+				"b.json":  {Data: []byte(`{} {}`)},
+				"c.csv":   {Data: []byte("a,b\n1\n")},
+				"d.csv":   {Data: []byte("a,a\n1,2\n")},
+				"ok.json": {Data: []byte(`{}`)},
+			},
+			wantError: "a.json: unexpected EOF\n" +
+				"b.json: unexpected data after JSON value\n" +
+				"c.csv: record on line 2: wrong number of fields\n" +
+				"d.csv: duplicate column \"a\"",
+		},
+		{
+			name: "reports files with the same key",
+			fsys: fstest.MapFS{
+				"team.csv":       {Data: []byte("name\nJane\n")},
+				"team.json":      {Data: []byte(`[]`)},
+				"docs.json":      {Data: []byte(`{}`)},
+				"docs/nav.json":  {Data: []byte(`{}`)},
+				"other/nav.json": {Data: []byte(`{}`)},
+			},
+			wantError: "docs.json: conflicts with docs/nav.json\n" +
+				"team.json: conflicts with team.csv",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			actual, err := data.FromFS(test.fsys)
+			if test.wantError != "" {
+				if err == nil || err.Error() != test.wantError {
+					t.Fatalf("expected error %q, got %v", test.wantError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(actual, test.want) {
+				t.Fatalf("expected %v, got %v", test.want, actual)
+			}
+		})
+	}
+}
+
+func TestFromFS_MissingDirectory(t *testing.T) {
+	t.Parallel()
+
+	fsys, err := fs.Sub(fstest.MapFS{"other/file.json": {}}, "data")
+	if err != nil {
+		t.Fatal(err)
+	}
+	actual, err := data.FromFS(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(actual) != 0 {
+		t.Fatalf("expected no data, got %v", actual)
+	}
+}
@@ -0,0 +1,173 @@
+package feeds
+
+import (
+	"encoding/xml"
+	"io"
+	"time"
+)
+
+// Feed is a format agnostic description of a feed. All links must be
+// absolute URLs.
+type Feed struct {
+	Title    string
+	Link     string
+	FeedLink string
+	Entries  []Entry
+}
+
+type Entry struct {
+	Title     string
+	Link      string
+	Published time.Time
+	Updated   *time.Time
+	// Content is HTML. It is escaped when the feed is written.
+	Content string
+}
+
+func (e Entry) updated() time.Time {
+	if e.Updated != nil {
+		return *e.Updated
+	}
+	return e.Published
+}
+
+// updated returns the most recent time any entry was published or updated.
+func (f *Feed) updated() time.Time {
+	latest := time.Time{}
+	for _, e := range f.Entries {
+		if u := e.updated(); u.After(latest) {
+			latest = u
+		}
+	}
+	return latest
+}
+
+type rss struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Atom    string     `xml:"xmlns:atom,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	AtomLink      atomLink  `xml:"atom:link"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link"`
+	GUID        rssGUID `xml:"guid"`
+	PubDate     string  `xml:"pubDate"`
+	Description string  `xml:"description"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+// WriteRSS writes f as an RSS 2.0 feed.
+func WriteRSS(w io.Writer, f *Feed) error {
+	items := make([]rssItem, 0, len(f.Entries))
+	for _, e := range f.Entries {
+		items = append(items, rssItem{
+			Title:       e.Title,
+			Link:        e.Link,
+			GUID:        rssGUID{IsPermaLink: true, Value: e.Link},
+			PubDate:     e.Published.UTC().Format(time.RFC1123Z),
+			Description: e.Content,
+		})
+	}
+	lastBuildDate := ""
+	if updated := f.updated(); !updated.IsZero() {
+		lastBuildDate = updated.UTC().Format(time.RFC1123Z)
+	}
+	return write(w, rss{
+		Version: "2.0",
+		Atom:    "http://www.w3.org/2005/Atom",
+		Channel: rssChannel{
+			Title:       f.Title,
+			Link:        f.Link,
+			Description: f.Title,
+			AtomLink: atomLink{
+				Href: f.FeedLink,
+				Rel:  "self",
+				Type: "application/rss+xml",
+			},
+			LastBuildDate: lastBuildDate,
+			Items:         items,
+		},
+	})
+}
+
+type atom struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Links   []atomLink  `xml:"link"`
+	Updated string      `xml:"updated"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomEntry struct {
+	Title     string      `xml:"title"`
+	ID        string      `xml:"id"`
+	Link      atomLink    `xml:"link"`
+	Published string      `xml:"published"`
+	Updated   string      `xml:"updated"`
+	Content   atomContent `xml:"content"`
+}
+
+type atomContent struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+// WriteAtom writes f as an Atom 1.0 feed.
+func WriteAtom(w io.Writer, f *Feed) error {
+	entries := make([]atomEntry, 0, len(f.Entries))
+	for _, e := range f.Entries {
+		entries = append(entries, atomEntry{
+			Title:     e.Title,
+			ID:        e.Link,
+			Link:      atomLink{Href: e.Link, Rel: "alternate"},
+			Published: e.Published.UTC().Format(time.RFC3339),
+			Updated:   e.updated().UTC().Format(time.RFC3339),
+			Content:   atomContent{Type: "html", Value: e.Content},
+		})
+	}
+	return write(w, atom{
+		Title: f.Title,
+		ID:    f.Link,
+		Links: []atomLink{
+			{Href: f.Link, Rel: "alternate"},
+			{Href: f.FeedLink, Rel: "self", Type: "application/atom+xml"},
+		},
+		Updated: f.updated().UTC().Format(time.RFC3339),
+		Entries: entries,
+	})
+}
+
+func write(w io.Writer, v any) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,137 @@
+package feeds_test
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fivethirty/satisficer/internal/builder/internal/feeds"
+	"github.com/fivethirty/satisficer/internal/testutil"
+)
+
+func TestWrite(t *testing.T) {
+	t.Parallel()
+
+	feed := &feeds.Feed{
+		Title:    "Posts & Notes",
+		Link:     "https://example.com/posts/",
+		FeedLink: "https://example.com/posts/feed.xml",
+		Entries: []feeds.Entry{
+			{
+				Title:     "<Second>",
+				Link:      "https://example.com/posts/second/",
+				Published: time.Date(2025, 5, 14, 12, 0, 0, 0, time.UTC),
+				Updated:   testutil.Ptr(t, time.Date(2025, 5, 15, 12, 0, 0, 0, time.UTC)),
+				Content:   "<p>Fish & chips</p>",
+			},
+			{
+				Title:     "First",
+				Link:      "https://example.com/posts/first/",
+				Published: time.Date(2025, 5, 13, 12, 0, 0, 0, time.FixedZone("", 3600)),
+				Content:   "<p>Hello</p>",
+			},
+		},
+	}
+
+	tests := []struct {
+		name  string
+		write func(io.Writer, *feeds.Feed) error
+		feed  *feeds.Feed
+		want  string
+	}{
+		{
+			name:  "rss",
+			write: feeds.WriteRSS,
+			feed:  feed,
+			want: `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:atom="http://www.w3.org/2005/Atom">
+  <channel>
+    <title>Posts &amp; Notes</title>
+    <link>https://example.com/posts/</link>
+    <description>Posts &amp; Notes</description>
+    <atom:link href="https://example.com/posts/feed.xml" rel="self" type="application/rss+xml"></atom:link>
+    <lastBuildDate>Thu, 15 May 2025 12:00:00 +0000</lastBuildDate>
+    <item>
+      <title>&lt;Second&gt;</title>
+      <link>https://example.com/posts/second/</link>
+      <guid isPermaLink="true">https://example.com/posts/second/</guid>
+      <pubDate>Wed, 14 May 2025 12:00:00 +0000</pubDate>
+      <description>&lt;p&gt;Fish &amp; chips&lt;/p&gt;</description>
+    </item>
+    <item>
+      <title>First</title>
+      <link>https://example.com/posts/first/</link>
+      <guid isPermaLink="true">https://example.com/posts/first/</guid>
+      <pubDate>Tue, 13 May 2025 11:00:00 +0000</pubDate>
+      <description>&lt;p&gt;Hello&lt;/p&gt;</description>
+    </item>
+  </channel>
+</rss>
+`,
+		},
+		{
+			name:  "atom",
+			write: feeds.WriteAtom,
+			feed:  feed,
+			want: `<?xml version="1.0" encoding="UTF-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <title>Posts &amp; Notes</title>
+  <id>https://example.com/posts/</id>
+  <link href="https://example.com/posts/" rel="alternate"></link>
+  <link href="https://example.com/posts/feed.xml" rel="self" type="application/atom+xml"></link>
+  <updated>2025-05-15T12:00:00Z</updated>
+  <entry>
+    <title>&lt;Second&gt;</title>
+    <id>https://example.com/posts/second/</id>
+    <link href="https://example.com/posts/second/" rel="alternate"></link>
+    <published>2025-05-14T12:00:00Z</published>
+    <updated>2025-05-15T12:00:00Z</updated>
+    <content type="html">&lt;p&gt;Fish &amp; chips&lt;/p&gt;</content>
+  </entry>
+  <entry>
+    <title>First</title>
+    <id>https://example.com/posts/first/</id>
+    <link href="https://example.com/posts/first/" rel="alternate"></link>
+    <published>2025-05-13T11:00:00Z</published>
+    <updated>2025-05-13T11:00:00Z</updated>
+    <content type="html">&lt;p&gt;Hello&lt;/p&gt;</content>
+  </entry>
+</feed>
+`,
+		},
+		{
+			name:  "empty rss",
+			write: feeds.WriteRSS,
+			feed: &feeds.Feed{
+				Title:    "Empty",
+				Link:     "https://example.com/",
+				FeedLink: "https://example.com/index.xml",
+			},
+			want: `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:atom="http://www.w3.org/2005/Atom">
+  <channel>
+    <title>Empty</title>
+    <link>https://example.com/</link>
+    <description>Empty</description>
+    <atom:link href="https://example.com/index.xml" rel="self" type="application/rss+xml"></atom:link>
+  </channel>
+</rss>
+`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			var sb strings.Builder
+			if err := test.write(&sb, test.feed); err != nil {
+				t.Fatal(err)
+			}
+			if sb.String() != test.want {
+				t.Fatalf("expected feed:\n%s\ngot:\n%s", test.want, sb.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,227 @@
+package funcs
+
+import (
+	"errors"
+	"fmt"
+	"html/template"
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/fivethirty/satisficer/internal/builder/internal/markdown"
+)
+
+type Options struct {
+	// BaseURL is the absolute URL of the site without a trailing slash. It
+	// may be empty.
+	BaseURL string
+	// Markdownify renders markdown to HTML.
+	Markdownify func(src []byte) (template.HTML, error)
+}
+
+// New returns the functions available to every template.
+func New(opts Options) map[string]any {
+	return map[string]any{
+		// Dates
+		"formatDate": formatDate,
+		"rfc3339": func(t any) (string, error) {
+			return formatDate(time.RFC3339, t)
+		},
+
+		// Strings
+		"lower":     strings.ToLower,
+		"upper":     strings.ToUpper,
+		"title":     title,
+		"trim":      strings.TrimSpace,
+		"replace":   strings.ReplaceAll,
+		"contains":  strings.Contains,
+		"hasPrefix": strings.HasPrefix,
+		"hasSuffix": strings.HasSuffix,
+		"split":     strings.Split,
+		"join":      join,
+		"truncate":  truncate,
+		"slugify":   markdown.Slugify,
+
+		// Math
+		"add": func(a, b int) int { return a + b },
+		"sub": func(a, b int) int { return a - b },
+		"mul": func(a, b int) int { return a * b },
+		"div": div,
+		"mod": mod,
+
+		// Collections
+		"dict":  dict,
+		"list":  func(values ...any) []any { return values },
+		"first": first,
+		"last":  last,
+		"in":    in,
+
+		// Content and URLs
+		"markdownify": func(src string) (template.HTML, error) {
+			return opts.Markdownify([]byte(src))
+		},
+		"absURL": func(p string) string {
+			return absURL(opts.BaseURL, p)
+		},
+		"relURL": func(p string) string {
+			return relURL(opts.BaseURL, p)
+		},
+	}
+}
+
+// formatDate formats t, a time.Time or *time.Time, with layout. A nil
+// *time.Time is formatted as an empty string.
+func formatDate(layout string, t any) (string, error) {
+	switch t := t.(type) {
+	case time.Time:
+		return t.Format(layout), nil
+	case *time.Time:
+		if t == nil {
+			return "", nil
+		}
+		return t.Format(layout), nil
+	default:
+		return "", fmt.Errorf("formatDate: expected a time, got %T", t)
+	}
+}
+
+// title upper cases the first letter of every word in s.
+func title(s string) string {
+	var b strings.Builder
+	start := true
+	for _, r := range s {
+		if start {
+			r = unicode.ToTitle(r)
+		}
+		start = unicode.IsSpace(r)
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// join takes the separator first so that it can be used in a pipeline such
+// as {{ .Tags | join ", " }}.
+func join(sep string, values []string) string {
+	return strings.Join(values, sep)
+}
+
+// truncate shortens s to at most n characters, ending it with an ellipsis if
+// anything was cut. Nothing is left of s if n is less than 1.
+func truncate(n int, s string) string {
+	if n < 1 {
+		return ""
+	}
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	cut := []rune(s)[:n-1]
+	return strings.TrimRightFunc(string(cut), unicode.IsSpace) + "…"
+}
+
+func div(a, b int) (int, error) {
+	if b == 0 {
+		return 0, errors.New("div: division by zero")
+	}
+	return a / b, nil
+}
+
+func mod(a, b int) (int, error) {
+	if b == 0 {
+		return 0, errors.New("mod: division by zero")
+	}
+	return a % b, nil
+}
+
+// dict creates a map from alternating keys and values, which is useful to
+// pass several values to a template.
+func dict(pairs ...any) (map[string]any, error) {
+	if len(pairs)%2 != 0 {
+		return nil, errors.New("dict: expected an even number of arguments")
+	}
+	d := make(map[string]any, len(pairs)/2)
+	for i := 0; i < len(pairs); i += 2 {
+		key, ok := pairs[i].(string)
+		if !ok {
+			return nil, fmt.Errorf("dict: expected a string key, got %T", pairs[i])
+		}
+		d[key] = pairs[i+1]
+	}
+	return d, nil
+}
+
+func list(name string, values any) (reflect.Value, error) {
+	v := reflect.ValueOf(values)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return reflect.Value{}, fmt.Errorf("%s: expected a slice, got %T", name, values)
+	}
+	return v, nil
+}
+
+// first returns the first n elements of values, or all of them if there are
+// fewer than n.
+func first(n int, values any) (any, error) {
+	v, err := list("first", values)
+	if err != nil {
+		return nil, err
+	}
+	return v.Slice(0, min(max(0, n), v.Len())).Interface(), nil
+}
+
+// last returns the last n elements of values, or all of them if there are
+// fewer than n.
+func last(n int, values any) (any, error) {
+	v, err := list("last", values)
+	if err != nil {
+		return nil, err
+	}
+	return v.Slice(max(0, v.Len()-max(0, n)), v.Len()).Interface(), nil
+}
+
+// in reports whether values contains value.
+func in(values any, value any) (bool, error) {
+	v, err := list("in", values)
+	if err != nil {
+		return false, err
+	}
+	for i := range v.Len() {
+		if reflect.DeepEqual(v.Index(i).Interface(), value) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// absURL returns the absolute URL of p, a path relative to the root of the
+// site. Absolute URLs are returned as they are. Without a base URL, p is made
+// relative to the root of the domain.
+func absURL(baseURL string, p string) string {
+	if isAbs(p) {
+		return p
+	}
+	return baseURL + "/" + strings.TrimPrefix(p, "/")
+}
+
+// relURL returns p, a path relative to the root of the site, relative to the
+// root of the domain, taking the path of the base URL into account. Absolute
+// URLs are returned as they are.
+func relURL(baseURL string, p string) string {
+	if isAbs(p) {
+		return p
+	}
+	prefix := ""
+	if u, err := url.Parse(baseURL); err == nil {
+		prefix = strings.TrimSuffix(u.Path, "/")
+	}
+	return prefix + "/" + strings.TrimPrefix(p, "/")
+}
+
+func isAbs(p string) bool {
+	if strings.HasPrefix(p, "//") {
+		return true
+	}
+	u, err := url.Parse(p)
+	return err == nil && u.IsAbs()
+}
@@ -0,0 +1,167 @@
+package funcs_test
+
+import (
+	"html/template"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fivethirty/satisficer/internal/builder/internal/funcs"
+)
+
+func TestFuncs(t *testing.T) {
+	t.Parallel()
+
+	date := time.Date(2025, 5, 13, 14, 30, 0, 0, time.UTC)
+	data := map[string]any{
+		"Date":    date,
+		"DatePtr": &date,
+		"NilDate": (*time.Time)(nil),
+		"Tags":    []string{"go", "web", "static"},
+	}
+
+	tests := []struct {
+		name      string
+		template  string
+		baseURL   string
+		want      string
+		wantError bool
+	}{
+		// Dates
+		{
+			name:     "formatDate time",
+			template: `{{ formatDate "2006-01-02" .Date }}`,
+			want:     "2025-05-13",
+		},
+		{
+			name:     "formatDate pointer",
+			template: `{{ formatDate "Jan 2, 2006" .DatePtr }}`,
+			want:     "May 13, 2025",
+		},
+		{name: "formatDate nil", template: `{{ formatDate "2006" .NilDate }}`, want: ""},
+		{name: "formatDate not a time", template: `{{ formatDate "2006" "x" }}`, wantError: true},
+		{name: "rfc3339", template: `{{ rfc3339 .DatePtr }}`, want: "2025-05-13T14:30:00Z"},
+
+		// Strings
+		{name: "lower", template: `{{ lower "Hello" }}`, want: "hello"},
+		{name: "upper", template: `{{ upper "Hello" }}`, want: "HELLO"},
+		{name: "title", template: `{{ title "hello big  world" }}`, want: "Hello Big  World"},
+		{name: "trim", template: `{{ trim "  hi  " }}`, want: "hi"},
+		{name: "replace", template: `{{ replace "a-b-c" "-" "_" }}`, want: "a_b_c"},
+		{name: "contains", template: `{{ contains "satisficer" "fic" }}`, want: "true"},
+		{name: "hasPrefix", template: `{{ hasPrefix "satisficer" "sat" }}`, want: "true"},
+		{name: "hasSuffix", template: `{{ hasSuffix "satisficer" "sat" }}`, want: "false"},
+		{name: "split", template: `{{ index (split "a,b" ",") 1 }}`, want: "b"},
+		{name: "join", template: `{{ .Tags | join ", " }}`, want: "go, web, static"},
+		{name: "truncate", template: `{{ "Hello world" | truncate 7 }}`, want: "Hello…"},
+		{name: "truncate short", template: `{{ "Hello" | truncate 5 }}`, want: "Hello"},
+		{name: "truncate runes", template: `{{ "héllo wörld" | truncate 4 }}`, want: "hél…"},
+		{name: "truncate to one", template: `{{ "Hello" | truncate 1 }}`, want: "…"},
+		{name: "truncate to zero", template: `{{ "Hello" | truncate 0 }}`, want: ""},
+		{name: "truncate negative", template: `{{ "Hello" | truncate -1 }}`, want: ""},
+		{name: "slugify", template: `{{ slugify "Static Sites!" }}`, want: "static-sites"},
+
+		// Math
+		{name: "add", template: `{{ add 1 2 }}`, want: "3"},
+		{name: "sub", template: `{{ sub 1 2 }}`, want: "-1"},
+		{name: "mul", template: `{{ mul 3 4 }}`, want: "12"},
+		{name: "div", template: `{{ div 7 2 }}`, want: "3"},
+		{name: "div by zero", template: `{{ div 7 0 }}`, wantError: true},
+		{name: "mod", template: `{{ mod 7 2 }}`, want: "1"},
+		{name: "mod by zero", template: `{{ mod 7 0 }}`, wantError: true},
+		{name: "math on len", template: `{{ sub (len .Tags) 1 }}`, want: "2"},
+
+		// Collections
+		{
+			name:     "dict",
+			template: `{{ $d := dict "a" 1 "b" "two" }}{{ $d.a }} {{ $d.b }}`,
+			want:     "1 two",
+		},
+		{name: "dict odd arguments", template: `{{ dict "a" }}`, wantError: true},
+		{name: "dict non-string key", template: `{{ dict 1 2 }}`, wantError: true},
+		{
+			name:     "list",
+			template: `{{ range list 1 "a" true }}{{ . }} {{ end }}`,
+			want:     "1 a true ",
+		},
+		{
+			name:     "built-in slice",
+			template: `{{ slice .Tags 1 }} {{ slice "abc" 1 2 }}`,
+			want:     "[web static] b",
+		},
+		{name: "first", template: `{{ first 2 .Tags }}`, want: "[go web]"},
+		{name: "first more than length", template: `{{ first 5 .Tags }}`, want: "[go web static]"},
+		{name: "first not a slice", template: `{{ first 1 "abc" }}`, wantError: true},
+		{name: "last", template: `{{ last 2 .Tags }}`, want: "[web static]"},
+		{name: "last more than length", template: `{{ last 5 .Tags }}`, want: "[go web static]"},
+		{name: "in", template: `{{ in .Tags "web" }} {{ in .Tags "rust" }}`, want: "true false"},
+
+		// Content and URLs
+		{
+			name:     "markdownify is not escaped",
+			template: `{{ markdownify "*hi*" }} {{ "<em>hi</em>" }}`,
+			want:     "<p><em>hi</em></p>\n &lt;em&gt;hi&lt;/em&gt;",
+		},
+		{
+			name:     "absURL",
+			template: `{{ absURL "posts/" }} {{ absURL "/css/main.css" }}`,
+			baseURL:  "https://example.com/blog",
+			want:     "https://example.com/blog/posts/ https://example.com/blog/css/main.css",
+		},
+		{
+			name:     "absURL without base url",
+			template: `{{ absURL "posts/" }}`,
+			want:     "/posts/",
+		},
+		{
+			name:     "absURL of absolute url",
+			template: `{{ absURL "https://other.com/a" }} {{ absURL "//cdn.com/a" }}`,
+			baseURL:  "https://example.com",
+			want:     "https://other.com/a //cdn.com/a",
+		},
+		{
+			name:     "relURL",
+			template: `{{ relURL "posts/" }} {{ relURL "/css/main.css" }}`,
+			baseURL:  "https://example.com/blog",
+			want:     "/blog/posts/ /blog/css/main.css",
+		},
+		{
+			name:     "relURL without base url",
+			template: `{{ relURL "posts/" }}`,
+			want:     "/posts/",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			fm := funcs.New(funcs.Options{
+				BaseURL: test.baseURL,
+				Markdownify: func(src []byte) (template.HTML, error) {
+					s := string(src)
+					s = strings.ReplaceAll(s, "*hi*", "<em>hi</em>")
+					return template.HTML("<p>" + s + "</p>\n"), nil
+				},
+			})
+			tmpl, err := template.New("").Funcs(fm).Parse(test.template)
+			if err != nil {
+				t.Fatal(err)
+			}
+			buf := &strings.Builder{}
+			err = tmpl.Execute(buf, data)
+			if err != nil {
+				if !test.wantError {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if test.wantError {
+				t.Fatalf("expected error, got %q", buf.String())
+			}
+			if buf.String() != test.want {
+				t.Fatalf("expected %q, got %q", test.want, buf.String())
+			}
+		})
+	}
+}
@@ -3,21 +3,65 @@ package layout
 import (
 	"errors"
 	"fmt"
+	htmltemplate "html/template"
 	"io"
 	"io/fs"
 	"log/slog"
+	"path"
+	"slices"
+	"sort"
 	"strings"
-	"text/template"
+	texttemplate "text/template"
+	"text/template/parse"
+
+	"github.com/fivethirty/satisficer/internal/builder/internal/manifest"
 )
 
+// Template is a template of a layout, either an HTML or a text template.
+type Template interface {
+	Name() string
+	Execute(w io.Writer, data any) error
+}
+
 type Layout struct {
-	Static    fs.FS
-	Templates *template.Template
+	Static fs.FS
+	// Templates holds the templates in *.html.tmpl files. Their output is
+	// escaped according to its context within the HTML document.
+	Templates *htmltemplate.Template
+	// TextTemplates holds the templates in every other *.tmpl file, such as
+	// feed.xml.tmpl. Their output is not escaped, which makes them suitable
+	// for other formats. They can only invoke other text templates.
+	TextTemplates *texttemplate.Template
+	html          *set
+	text          *set
+}
+
+// set tracks where the templates of one kind came from.
+type set struct {
+	// trees maps each template name to its parse tree.
+	trees map[string]*parse.Tree
+	// sources maps each template name to a hash of the file that defined it.
+	sources map[string]string
 }
 
 const StaticDir = "static"
 
-func FromFS(fsys fs.FS) (*Layout, error) {
+// IsHTML reports whether the template in the named file is an HTML template.
+func IsHTML(name string) bool {
+	return strings.HasSuffix(name, ".html.tmpl")
+}
+
+// Ext returns the extension of the files a template renders, such as ".html"
+// for page.html.tmpl and ".xml" for feed.xml.tmpl.
+func Ext(name string) string {
+	if IsHTML(name) || !strings.HasSuffix(name, ".tmpl") {
+		return ".html"
+	}
+	return path.Ext(strings.TrimSuffix(name, ".tmpl"))
+}
+
+// FromFS loads the layout in fsys. Templates can call any of funcs.
+func FromFS(fsys fs.FS, funcs map[string]any) (*Layout, error) {
 	info, err := fs.Stat(fsys, StaticDir)
 	if err != nil && !errors.Is(err, fs.ErrNotExist) {
 		return nil, err
@@ -35,19 +79,41 @@ func FromFS(fsys fs.FS) (*Layout, error) {
 		static = sub
 	}
 
-	tmpl, err := templates(fsys)
-	if err != nil {
-		return nil, fmt.Errorf("failed to load templates: %w", err)
+	l := &Layout{
+		Static:        static,
+		Templates:     htmltemplate.New("").Funcs(funcs),
+		TextTemplates: texttemplate.New("").Funcs(funcs),
+		html:          newSet(),
+		text:          newSet(),
 	}
+	if err := l.loadTemplates(fsys); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
 
-	return &Layout{
-		Static:    static,
-		Templates: tmpl,
-	}, nil
+func newSet() *set {
+	return &set{
+		trees:   make(map[string]*parse.Tree),
+		sources: make(map[string]string),
+	}
 }
 
-func templates(fsys fs.FS) (*template.Template, error) {
-	tmpl := template.New("")
+// attribute records hash as the source of every template whose tree is not
+// the one it had before.
+func (s *set) attribute(trees map[string]*parse.Tree, hash string) {
+	for name, tree := range trees {
+		if s.trees[name] != tree {
+			s.trees[name] = tree
+			s.sources[name] = hash
+		}
+	}
+}
+
+// loadTemplates parses every template in fsys. Parsing carries on past
+// templates with syntax errors so that all of them are reported together.
+func (t *Layout) loadTemplates(fsys fs.FS) error {
+	parseErrs := []error{}
 	err := fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
@@ -59,7 +125,7 @@ func templates(fsys fs.FS) (*template.Template, error) {
 			}
 			return nil
 		}
-		if !strings.HasSuffix(path, ".html.tmpl") {
+		if !strings.HasSuffix(path, ".tmpl") {
 			return nil
 		}
 
@@ -75,25 +141,212 @@ func templates(fsys fs.FS) (*template.Template, error) {
 		if err != nil {
 			return err
 		}
-		_, err = tmpl.New(path).Parse(string(bytes))
-		if err != nil {
-			return err
+
+		// A file can define any number of named templates, and can redefine
+		// ones defined by earlier files, so attribute every template whose
+		// tree changed to this file.
+		hash := manifest.Hash(string(bytes))
+		if IsHTML(path) {
+			if _, err := t.Templates.New(path).Parse(string(bytes)); err != nil {
+				parseErrs = append(parseErrs, err)
+				return nil
+			}
+			trees := make(map[string]*parse.Tree)
+			for _, tmpl := range t.Templates.Templates() {
+				trees[tmpl.Name()] = tmpl.Tree
+			}
+			t.html.attribute(trees, hash)
+		} else {
+			if _, err := t.TextTemplates.New(path).Parse(string(bytes)); err != nil {
+				parseErrs = append(parseErrs, err)
+				return nil
+			}
+			trees := make(map[string]*parse.Tree)
+			for _, tmpl := range t.TextTemplates.Templates() {
+				trees[tmpl.Name()] = tmpl.Tree
+			}
+			t.text.attribute(trees, hash)
 		}
 		return nil
 	})
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("failed to load templates: %w", err)
 	}
-	return tmpl, nil
+	return errors.Join(parseErrs...)
 }
 
+// Lookup returns the template in the named file, or nil if there is none.
+func (t *Layout) Lookup(name string) Template {
+	if IsHTML(name) {
+		if tmpl := t.Templates.Lookup(name); tmpl != nil {
+			return tmpl
+		}
+	} else if tmpl := t.TextTemplates.Lookup(name); tmpl != nil {
+		return tmpl
+	}
+	return nil
+}
+
+// TemplateForContent returns the first of templateFiles that exists, for the
+// content at contentPath. The error names every file tried when none does.
 func (t *Layout) TemplateForContent(
 	contentPath string,
-	templateFile string,
-) (*template.Template, error) {
-	tmpl := t.Templates.Lookup(templateFile)
-	if tmpl == nil {
-		return nil, fmt.Errorf("template %s for %s not found", templateFile, contentPath)
+	templateFiles ...string,
+) (Template, error) {
+	for _, templateFile := range templateFiles {
+		if tmpl := t.Lookup(templateFile); tmpl != nil {
+			return tmpl, nil
+		}
+	}
+	if len(templateFiles) == 1 {
+		return nil, fmt.Errorf("%s: template %s not found", contentPath, templateFiles[0])
+	}
+	return nil, fmt.Errorf(
+		"%s: no template found, tried %s",
+		contentPath,
+		strings.Join(templateFiles, ", "),
+	)
+}
+
+// set returns the templates the template in the named file belongs to.
+func (t *Layout) set(name string) *set {
+	if IsHTML(name) {
+		return t.html
+	}
+	return t.text
+}
+
+// Fingerprint returns a hash of the source of the named template and of every
+// template it invokes, directly or indirectly.
+func (t *Layout) Fingerprint(name string) string {
+	set := t.set(name)
+	names := set.closure(name)
+	parts := make([]string, 0, 2*len(names))
+	for _, name := range names {
+		parts = append(parts, name, set.sources[name])
+	}
+	return manifest.Hash(parts...)
+}
+
+// Uses reports whether the named template, or any template it invokes,
+// refers to a field or method with any of the given names. Fields reached
+// through index with a key other than a number, such as index . "Site", can't
+// be told apart, so such templates are reported as using every field.
+func (t *Layout) Uses(name string, fields ...string) bool {
+	set := t.set(name)
+	for _, name := range set.closure(name) {
+		tree := set.trees[name]
+		if tree == nil {
+			continue
+		}
+		found := false
+		walk(tree.Root, func(node parse.Node) {
+			var idents []string
+			switch n := node.(type) {
+			case *parse.FieldNode:
+				idents = n.Ident
+			case *parse.ChainNode:
+				idents = n.Field
+			case *parse.VariableNode:
+				idents = n.Ident[1:]
+			case *parse.CommandNode:
+				found = found || indexesByKey(n)
+			}
+			for _, ident := range idents {
+				found = found || slices.Contains(fields, ident)
+			}
+		})
+		if found {
+			return true
+		}
+	}
+	return false
+}
+
+// indexesByKey reports whether cmd calls index with a key that is not a
+// number.
+func indexesByKey(cmd *parse.CommandNode) bool {
+	if len(cmd.Args) < 2 {
+		return false
+	}
+	if ident, ok := cmd.Args[0].(*parse.IdentifierNode); !ok || ident.Ident != "index" {
+		return false
+	}
+	for _, arg := range cmd.Args[2:] {
+		if _, ok := arg.(*parse.NumberNode); !ok {
+			return true
+		}
+	}
+	return false
+}
+
+// closure returns the sorted names of the named template and of every
+// template it invokes, directly or indirectly.
+func (s *set) closure(name string) []string {
+	seen := make(map[string]struct{})
+	var visit func(name string)
+	visit = func(name string) {
+		if _, ok := seen[name]; ok {
+			return
+		}
+		seen[name] = struct{}{}
+		tree := s.trees[name]
+		if tree == nil {
+			return
+		}
+		walk(tree.Root, func(node parse.Node) {
+			if n, ok := node.(*parse.TemplateNode); ok {
+				visit(n.Name)
+			}
+		})
+	}
+	visit(name)
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// walk calls fn for node and every node below it.
+func walk(node parse.Node, fn func(parse.Node)) {
+	fn(node)
+	switch n := node.(type) {
+	case *parse.ListNode:
+		for _, child := range n.Nodes {
+			walk(child, fn)
+		}
+	case *parse.ActionNode:
+		walk(n.Pipe, fn)
+	case *parse.PipeNode:
+		for _, cmd := range n.Cmds {
+			walk(cmd, fn)
+		}
+	case *parse.CommandNode:
+		for _, arg := range n.Args {
+			walk(arg, fn)
+		}
+	case *parse.ChainNode:
+		walk(n.Node, fn)
+	case *parse.IfNode:
+		walkBranch(&n.BranchNode, fn)
+	case *parse.RangeNode:
+		walkBranch(&n.BranchNode, fn)
+	case *parse.WithNode:
+		walkBranch(&n.BranchNode, fn)
+	case *parse.TemplateNode:
+		if n.Pipe != nil {
+			walk(n.Pipe, fn)
+		}
+	}
+}
+
+func walkBranch(n *parse.BranchNode, fn func(parse.Node)) {
+	walk(n.Pipe, fn)
+	walk(n.List, fn)
+	if n.ElseList != nil {
+		walk(n.ElseList, fn)
 	}
-	return tmpl, nil
 }
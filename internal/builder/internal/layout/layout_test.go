@@ -1,8 +1,10 @@
 package layout_test
 
 import (
+	"maps"
 	"reflect"
 	"sort"
+	"strings"
 	"testing"
 	"testing/fstest"
 
@@ -99,7 +101,7 @@ func TestFromFS(t *testing.T) {
 		t.Run(test.name, func(t *testing.T) {
 			t.Parallel()
 
-			l, err := layout.FromFS(test.fs)
+			l, err := layout.FromFS(test.fs, nil)
 			if err != nil {
 				if !test.wantError {
 					t.Fatalf("unexpected error: %v", err)
@@ -112,6 +114,10 @@ func TestFromFS(t *testing.T) {
 
 			templateNames := make([]string, 0, len(test.wantTemplatePaths))
 			for _, tmpl := range l.Templates.Templates() {
+				// Skip the unnamed template that holds all the others.
+				if tmpl.Name() == "" {
+					continue
+				}
 				templateNames = append(templateNames, tmpl.Name())
 			}
 
@@ -144,45 +150,67 @@ func TestTemplateForContent(t *testing.T) {
 	tests := []struct {
 		name             string
 		contentPath      string
-		templateFile     string
+		templateFiles    []string
 		fs               fstest.MapFS
 		wantTemplateName string
-		wantErr          bool
+		wantErr          string
 	}{
 		{
-			name:         "finds template when it exists",
-			contentPath:  "about.md",
-			templateFile: "page.html.tmpl",
+			name:          "finds template when it exists",
+			contentPath:   "about.md",
+			templateFiles: []string{"page.html.tmpl"},
 			fs: fstest.MapFS{
 				"page.html.tmpl": testFile,
 			},
 			wantTemplateName: "page.html.tmpl",
 		},
 		{
-			name:         "returns error when template not found",
-			contentPath:  "about.md",
-			templateFile: "missing.html.tmpl",
-			fs:           fstest.MapFS{},
-			wantErr:      true,
+			name:          "returns error when template not found",
+			contentPath:   "about.md",
+			templateFiles: []string{"missing.html.tmpl"},
+			fs:            fstest.MapFS{},
+			wantErr:       "about.md: template missing.html.tmpl not found",
+		},
+		{
+			name:        "finds the first template that exists",
+			contentPath: "blog/post.md",
+			templateFiles: []string{
+				"blog/page.html.tmpl",
+				"post.html.tmpl",
+				"_default/page.html.tmpl",
+			},
+			fs: fstest.MapFS{
+				"post.html.tmpl":          testFile,
+				"_default/page.html.tmpl": testFile,
+			},
+			wantTemplateName: "post.html.tmpl",
+		},
+		{
+			name:          "names every template tried",
+			contentPath:   "blog/post.md",
+			templateFiles: []string{"blog/page.html.tmpl", "_default/page.html.tmpl"},
+			fs:            fstest.MapFS{},
+			wantErr: "blog/post.md: no template found, tried " +
+				"blog/page.html.tmpl, _default/page.html.tmpl",
 		},
 	}
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			t.Parallel()
-			l, err := layout.FromFS(test.fs)
+			l, err := layout.FromFS(test.fs, nil)
 			if err != nil {
 				t.Fatalf("failed to create templates: %v", err)
 			}
 
-			tmpl, err := l.TemplateForContent(test.contentPath, test.templateFile)
+			tmpl, err := l.TemplateForContent(test.contentPath, test.templateFiles...)
 			if err != nil {
-				if !test.wantErr {
-					t.Fatalf("unexpected error: %v", err)
+				if err.Error() != test.wantErr {
+					t.Fatalf("expected error %q, got %q", test.wantErr, err)
 				}
 				return
 			}
-			if test.wantErr {
+			if test.wantErr != "" {
 				t.Fatalf("expected error, got nil")
 			}
 			if test.wantTemplateName != tmpl.Name() {
@@ -191,3 +219,169 @@ func TestTemplateForContent(t *testing.T) {
 		})
 	}
 }
+
+func TestUses(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		template string
+		fs       fstest.MapFS
+		want     bool
+	}{
+		{
+			name:     "field",
+			template: `{{ .Site.Title }}`,
+			want:     true,
+		},
+		{
+			name:     "field of root variable",
+			template: `{{ range .Others }}{{ $.Site.Title }}{{ end }}`,
+			want:     true,
+		},
+		{
+			name:     "field of parenthesized pipeline",
+			template: `{{ with .Current }}{{ else }}{{ len (.Site.Pages) }}{{ end }}`,
+			want:     true,
+		},
+		{
+			name:     "field used by invoked template",
+			template: `{{ template "nav" . }}`,
+			fs: fstest.MapFS{
+				"nav.html.tmpl": {Data: []byte(`{{ define "nav" }}{{ .Site }}{{ end }}`)},
+			},
+			want: true,
+		},
+		{
+			name:     "field of variable",
+			template: `{{ $page := . }}{{ with $page.Site }}{{ .Title }}{{ end }}`,
+			want:     true,
+		},
+		{
+			name:     "field of value passed through dict",
+			template: `{{ template "nav" (dict "page" .) }}`,
+			fs: fstest.MapFS{
+				"nav.html.tmpl": {Data: []byte(`{{ define "nav" }}{{ .page.Site }}{{ end }}`)},
+			},
+			want: true,
+		},
+		{
+			name:     "index with string key",
+			template: `{{ (index . "Site").Title }}`,
+			want:     true,
+		},
+		{
+			name:     "index with variable key",
+			template: `{{ $key := "Current" }}{{ index . $key }}`,
+			want:     true,
+		},
+		{
+			name:     "index with number keys",
+			template: `{{ index .Others 0 }}{{ index .Grid 1 2 }}`,
+		},
+		{
+			name:     "other fields only",
+			template: `{{ .Current.Title }}{{ range .Others }}{{ .SiteName }}{{ end }}`,
+		},
+		{
+			name:     "field name in text",
+			template: `Site {{ "Site" }}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			fs := fstest.MapFS{
+				"page.html.tmpl": {Data: []byte(test.template)},
+			}
+			maps.Copy(fs, test.fs)
+			funcs := map[string]any{"dict": func(...any) map[string]any { return nil }}
+			l, err := layout.FromFS(fs, funcs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := l.Uses("page.html.tmpl", "Site"); got != test.want {
+				t.Fatalf("expected %t, got %t", test.want, got)
+			}
+		})
+	}
+}
+
+func TestTextTemplates(t *testing.T) {
+	t.Parallel()
+
+	// Both kinds of templates define "title" without clashing.
+	l, err := layout.FromFS(fstest.MapFS{
+		"page.html.tmpl": {
+			Data: []byte(`{{ define "title" }}({{ . }}){{ end }}{{ template "title" . }}`),
+		},
+		"feed.xml.tmpl": {
+			Data: []byte(`{{ define "title" }}[{{ . }}]{{ end }}{{ template "title" . }}`),
+		},
+		"robots.txt.tmpl": {Data: []byte(`{{ .Site }}`)},
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		template string
+		want     string
+		wantUses bool
+	}{
+		{name: "page.html.tmpl", template: "page.html.tmpl", want: "(a &amp; b)"},
+		{name: "feed.xml.tmpl", template: "feed.xml.tmpl", want: "[a & b]"},
+		{name: "robots.txt.tmpl", template: "robots.txt.tmpl", wantUses: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			tmpl := l.Lookup(test.template)
+			if tmpl == nil {
+				t.Fatalf("expected to find %s", test.template)
+			}
+			if uses := l.Uses(test.template, "Site"); uses != test.wantUses {
+				t.Fatalf("expected uses %t, got %t", test.wantUses, uses)
+			}
+			if test.want == "" {
+				return
+			}
+			buf := &strings.Builder{}
+			if err := tmpl.Execute(buf, "a & b"); err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != test.want {
+				t.Fatalf("expected %q, got %q", test.want, buf.String())
+			}
+		})
+	}
+
+	if l.Lookup("missing.xml.tmpl") != nil {
+		t.Fatal("expected no template for missing.xml.tmpl")
+	}
+	if l.Fingerprint("page.html.tmpl") == l.Fingerprint("feed.xml.tmpl") {
+		t.Fatal("expected templates from different files to have different fingerprints")
+	}
+}
+
+func TestExt(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]string{
+		"page.html.tmpl":      ".html",
+		"blog/page.html.tmpl": ".html",
+		"feed.xml.tmpl":       ".xml",
+		"robots.txt.tmpl":     ".txt",
+		"page":                ".html",
+		"":                    ".html",
+	}
+	for name, want := range tests {
+		if got := layout.Ext(name); got != want {
+			t.Fatalf("expected %q for %q, got %q", want, name, got)
+		}
+	}
+}
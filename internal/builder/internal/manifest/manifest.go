@@ -0,0 +1,153 @@
+package manifest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fivethirty/satisficer/internal/fsutil"
+)
+
+// Manifest records which outputs a build wrote to a directory and the
+// fingerprint of the inputs they were rendered from, so that later builds
+// into the same directory can skip outputs whose inputs have not changed.
+type Manifest struct {
+	path    string
+	entries map[string]Entry
+	kept    map[string]struct{}
+}
+
+type Entry struct {
+	Hash    string    `json:"hash"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"modTime"`
+}
+
+// Load reads the manifest stored at path. A missing file results in an
+// empty manifest. If path is empty the manifest is only kept in memory.
+func Load(path string) (*Manifest, error) {
+	m := &Manifest{
+		path:    path,
+		entries: make(map[string]Entry),
+		kept:    make(map[string]struct{}),
+	}
+	if path == "" {
+		return m, nil
+	}
+	b, err := os.ReadFile(filepath.Clean(path))
+	if errors.Is(err, fs.ErrNotExist) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &m.entries); err != nil {
+		slog.Warn("Ignoring unreadable build manifest", "path", path, "error", err)
+		m.entries = make(map[string]Entry)
+	}
+	return m, nil
+}
+
+// Begin starts tracking which outputs the next build keeps or writes.
+func (m *Manifest) Begin() {
+	m.kept = make(map[string]struct{})
+}
+
+// Fresh reports whether the output at path inside buildDir was written from
+// inputs with the given hash and has not been modified since. Fresh outputs
+// are kept when the manifest is pruned.
+func (m *Manifest) Fresh(buildDir string, path string, hash string) bool {
+	entry, ok := m.entries[path]
+	if !ok || entry.Hash != hash {
+		return false
+	}
+	info, err := os.Stat(filepath.Join(buildDir, path))
+	if err != nil || info.Size() != entry.Size || !info.ModTime().Equal(entry.ModTime) {
+		return false
+	}
+	m.kept[path] = struct{}{}
+	return true
+}
+
+// Record stores the hash of the inputs the output at path was just written
+// from.
+func (m *Manifest) Record(buildDir string, path string, hash string) error {
+	info, err := os.Stat(filepath.Join(buildDir, path))
+	if err != nil {
+		return err
+	}
+	m.entries[path] = Entry{
+		Hash:    hash,
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
+	}
+	m.kept[path] = struct{}{}
+	return nil
+}
+
+// Prune deletes outputs written by a previous build that were neither kept
+// nor written by the current one.
+func (m *Manifest) Prune(buildDir string) error {
+	for path := range m.entries {
+		if _, ok := m.kept[path]; ok {
+			continue
+		}
+		slog.Info("Removing stale file", "path", path)
+		err := os.Remove(filepath.Join(buildDir, path))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		delete(m.entries, path)
+	}
+	return nil
+}
+
+func (m *Manifest) Save() error {
+	if m.path == "" {
+		return nil
+	}
+	b, err := json.Marshal(m.entries)
+	if err != nil {
+		return err
+	}
+	f, err := fsutil.CreateFile(m.path)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+	_, err = f.Write(b)
+	return err
+}
+
+// Hash returns a hex encoded digest of parts. Parts are length prefixed so
+// that different splits of the same bytes hash differently.
+func Hash(parts ...string) string {
+	h := sha256.New()
+	for _, part := range parts {
+		_, _ = fmt.Fprintf(h, "%d:", len(part))
+		_, _ = io.WriteString(h, part)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// HashFile returns a hex encoded digest of the contents of path in fsys.
+func HashFile(fsys fs.FS, path string) (string, error) {
+	f, err := fsys.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = f.Close() }()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
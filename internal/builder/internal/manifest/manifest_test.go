@@ -0,0 +1,140 @@
+package manifest_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/fivethirty/satisficer/internal/builder/internal/manifest"
+)
+
+const filePerm = 0o644
+
+func TestManifest(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		hash      string
+		modify    func(t *testing.T, path string)
+		wantFresh bool
+	}{
+		{
+			name:      "fresh when hash and file match",
+			hash:      "a",
+			modify:    func(_ *testing.T, _ string) {},
+			wantFresh: true,
+		},
+		{
+			name:   "stale when hash differs",
+			hash:   "b",
+			modify: func(_ *testing.T, _ string) {},
+		},
+		{
+			name: "stale when file was modified",
+			hash: "a",
+			modify: func(t *testing.T, path string) {
+				if err := os.WriteFile(path, []byte("modified"), filePerm); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+		{
+			name: "stale when file was deleted",
+			hash: "a",
+			modify: func(t *testing.T, path string) {
+				if err := os.Remove(path); err != nil {
+					t.Fatal(err)
+				}
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			buildDir := t.TempDir()
+			manifestPath := filepath.Join(t.TempDir(), "manifest.json")
+			outPath := filepath.Join(buildDir, "out.html")
+			if err := os.WriteFile(outPath, []byte("out"), filePerm); err != nil {
+				t.Fatal(err)
+			}
+
+			m, err := manifest.Load(manifestPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			m.Begin()
+			if err := m.Record(buildDir, "out.html", "a"); err != nil {
+				t.Fatal(err)
+			}
+			if err := m.Save(); err != nil {
+				t.Fatal(err)
+			}
+
+			test.modify(t, outPath)
+
+			loaded, err := manifest.Load(manifestPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			loaded.Begin()
+			if fresh := loaded.Fresh(buildDir, "out.html", test.hash); fresh != test.wantFresh {
+				t.Fatalf("expected fresh to be %t, got %t", test.wantFresh, fresh)
+			}
+		})
+	}
+}
+
+func TestManifest_Prune(t *testing.T) {
+	t.Parallel()
+
+	buildDir := t.TempDir()
+	for _, name := range []string{"kept.html", "stale.html", "unmanaged.html"} {
+		if err := os.WriteFile(filepath.Join(buildDir, name), []byte(name), filePerm); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m, err := manifest.Load("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Begin()
+	for _, name := range []string{"kept.html", "stale.html"} {
+		if err := m.Record(buildDir, name, "hash"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m.Begin()
+	if !m.Fresh(buildDir, "kept.html", "hash") {
+		t.Fatal("expected kept.html to be fresh")
+	}
+	if err := m.Prune(buildDir); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, wantExists := range map[string]bool{
+		"kept.html":      true,
+		"stale.html":     false,
+		"unmanaged.html": true,
+	} {
+		_, err := os.Stat(filepath.Join(buildDir, name))
+		if exists := err == nil; exists != wantExists {
+			t.Fatalf("expected %s to exist: %t, got %t", name, wantExists, exists)
+		}
+	}
+}
+
+func TestHash(t *testing.T) {
+	t.Parallel()
+
+	if manifest.Hash("ab", "c") == manifest.Hash("a", "bc") {
+		t.Fatal("expected different splits of the same input to hash differently")
+	}
+	if manifest.Hash("a", "b") != manifest.Hash("a", "b") {
+		t.Fatal("expected hash to be deterministic")
+	}
+}
@@ -0,0 +1,139 @@
+package markdown
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"strings"
+
+	"github.com/fivethirty/satisficer/internal/highlight"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// Highlight configures syntax highlighting of fenced code blocks.
+type Highlight struct {
+	Enabled bool
+	// LineNumbers numbers the lines of every code block, unless its info
+	// string turns them off.
+	LineNumbers bool
+}
+
+var codeBlockErrorsKey = parser.NewContextKey()
+
+// codeBlockTransformer stores the errors in the options of fenced code blocks
+// in the parser context under codeBlockErrorsKey. Such blocks are rendered
+// without highlighting rather than failing the page.
+type codeBlockTransformer struct {
+	lineNumbers bool
+}
+
+func (t *codeBlockTransformer) Transform(
+	node *ast.Document,
+	reader text.Reader,
+	pc parser.Context,
+) {
+	var errs []error
+	_ = ast.Walk(node, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		block, ok := n.(*ast.FencedCodeBlock)
+		if !entering || !ok {
+			return ast.WalkContinue, nil
+		}
+		if _, _, err := codeBlockOptions(reader.Source(), block, t.lineNumbers); err != nil {
+			errs = append(errs, positionError(reader.Source(), block.Info.Segment.Start, err))
+		}
+		return ast.WalkContinue, nil
+	})
+	pc.Set(codeBlockErrorsKey, errs)
+}
+
+// codeBlockRenderer renders fenced code blocks with highlighted code in a
+// <pre class="highlight"> element.
+type codeBlockRenderer struct {
+	lineNumbers bool
+}
+
+func (r *codeBlockRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(ast.KindFencedCodeBlock, r.render)
+}
+
+func (r *codeBlockRenderer) render(
+	w util.BufWriter,
+	source []byte,
+	node ast.Node,
+	entering bool,
+) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	n := node.(*ast.FencedCodeBlock)
+	lang, opts, optsErr := codeBlockOptions(source, n, r.lineNumbers)
+
+	code := &bytes.Buffer{}
+	lines := n.Lines()
+	for i := range lines.Len() {
+		segment := lines.At(i)
+		code.Write(segment.Value(source))
+	}
+
+	if optsErr != nil {
+		_, _ = w.WriteString("<pre><code")
+	} else {
+		_, _ = w.WriteString(`<pre class="highlight"><code`)
+	}
+	if lang != "" {
+		_, _ = w.WriteString(` class="language-` + html.EscapeString(lang) + `"`)
+	}
+	_ = w.WriteByte('>')
+	if optsErr != nil {
+		_, _ = w.WriteString(html.EscapeString(code.String()))
+	} else if err := highlight.WriteHTML(w, lang, code.String(), opts); err != nil {
+		return ast.WalkStop, positionError(source, n.Info.Segment.Start, err)
+	}
+	_, _ = w.WriteString("</code></pre>\n")
+	return ast.WalkContinue, nil
+}
+
+// codeBlockOptions returns the language of n and the options in its info
+// string, starting from lineNumbers. It fails if the options are invalid or
+// mark lines past the end of the block.
+func codeBlockOptions(
+	source []byte,
+	n *ast.FencedCodeBlock,
+	lineNumbers bool,
+) (string, highlight.Options, error) {
+	opts := highlight.Options{LineNumbers: lineNumbers}
+	if n.Info == nil {
+		return "", opts, nil
+	}
+	info := string(n.Info.Segment.Value(source))
+	lang := ""
+	if !strings.HasPrefix(info, "{") {
+		lang = string(n.Language(source))
+	}
+	opts, err := highlight.ParseOptions(info[len(lang):], opts)
+	if err != nil {
+		return lang, opts, err
+	}
+	for _, line := range opts.Marked {
+		if line > n.Lines().Len() {
+			return lang, opts, fmt.Errorf("cannot highlight line %d of %d", line, n.Lines().Len())
+		}
+	}
+	return lang, opts, nil
+}
+
+// positionError locates err at offset in source.
+func positionError(source []byte, offset int, err error) error {
+	line, column := offsetPosition(source, offset)
+	return &PositionError{Line: line, Column: column, Err: err}
+}
+
+// offsetPosition returns the line and column of offset in source.
+func offsetPosition(source []byte, offset int) (line int, column int) {
+	prefix := source[:offset]
+	return bytes.Count(prefix, []byte{'\n'}) + 1, len(prefix) - bytes.LastIndexByte(prefix, '\n')
+}
@@ -0,0 +1,103 @@
+package markdown
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+)
+
+// Link is a link from a page to another markdown file in the content, such
+// as [see setup](../setup.md#install).
+type Link struct {
+	// Dest is the destination of the link as written.
+	Dest string
+	// Path is the markdown file linked to, relative to the directory of the
+	// page, and Fragment is the part of Dest after '#', if any.
+	Path     string
+	Fragment string
+	// Line and Column locate the link in the page.
+	Line   int
+	Column int
+	// URL is where the link points once the page linked to is rendered. It
+	// is left empty by Parse, which does not know where pages are rendered.
+	URL string
+}
+
+var (
+	linksKey    = parser.NewContextKey()
+	linkURLsKey = parser.NewContextKey()
+)
+
+// link is a Link located by its offset in the markdown content, until the
+// content is known to start on a given line of the file.
+type link struct {
+	Link
+	offset int
+}
+
+// contentLinkTransformer stores the links to markdown files in the parser
+// context under linksKey, and points those whose destination is a key of the
+// map under linkURLsKey at the URL it maps to.
+type contentLinkTransformer struct{}
+
+func (t *contentLinkTransformer) Transform(
+	node *ast.Document,
+	reader text.Reader,
+	pc parser.Context,
+) {
+	urls, _ := pc.Get(linkURLsKey).(map[string]string)
+	links := []link{}
+	_ = ast.Walk(node, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		l, ok := n.(*ast.Link)
+		if !entering || !ok {
+			return ast.WalkContinue, nil
+		}
+		dest := string(l.Destination)
+		p, fragment, ok := contentLink(dest)
+		if !ok {
+			return ast.WalkContinue, nil
+		}
+		links = append(links, link{
+			Link:   Link{Dest: dest, Path: p, Fragment: fragment},
+			offset: linkOffset(l),
+		})
+		if u, ok := urls[dest]; ok {
+			l.Destination = []byte(u)
+		}
+		return ast.WalkContinue, nil
+	})
+	pc.Set(linksKey, links)
+}
+
+// contentLink returns the path and fragment of dest if it is a relative link
+// to a markdown file.
+func contentLink(dest string) (p string, fragment string, ok bool) {
+	u, err := url.Parse(dest)
+	if err != nil || u.Scheme != "" || u.Host != "" || u.RawQuery != "" {
+		return "", "", false
+	}
+	if strings.HasPrefix(u.Path, "/") || !strings.HasSuffix(u.Path, ".md") {
+		return "", "", false
+	}
+	return u.Path, u.Fragment, true
+}
+
+// linkOffset returns the offset of the '[' that starts l, or close to it when
+// its text starts with markup. Links without text are located at the start
+// of their block.
+func linkOffset(l *ast.Link) int {
+	for n := l.FirstChild(); n != nil; n = n.FirstChild() {
+		if t, ok := n.(*ast.Text); ok {
+			return t.Segment.Start - 1
+		}
+	}
+	for n := l.Parent(); n != nil; n = n.Parent() {
+		if n.Type() == ast.TypeBlock && n.Lines().Len() > 0 {
+			return n.Lines().At(0).Start
+		}
+	}
+	return 0
+}
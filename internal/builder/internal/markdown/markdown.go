@@ -3,30 +3,114 @@ package markdown
 import (
 	"bufio"
 	"bytes"
+	"cmp"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"html/template"
 	"io"
+	"reflect"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/yuin/goldmark"
 	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/extension"
 	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/renderer/html"
 	"github.com/yuin/goldmark/text"
 	"github.com/yuin/goldmark/util"
 )
 
 type ParsedFile struct {
 	FrontMatter FrontMatter
-	HTML        string
+	HTML        template.HTML
+	// TOC is the table of contents of the page, the headings within the
+	// levels of Options.TOC, and TOCHTML is the same as nested lists of links.
+	TOC     []Heading
+	TOCHTML template.HTML
+	// Summary is a short form of HTML for listings, see render.
+	Summary template.HTML
+	// Links holds the links to other markdown files in HTML and Summary,
+	// which ParseWithURLs points to the pages rendered from them.
+	Links []Link
+	// Plain is the text of the page without markup, and WordCount the number
+	// of words in it.
+	Plain     string
+	WordCount int
+	// ReadingTime is the number of minutes it takes to read the page at
+	// WordsPerMinute, rounded up.
+	ReadingTime int
+	// Warnings holds problems that did not stop the file from rendering,
+	// such as code blocks left unhighlighted because of invalid options.
+	Warnings []error
 }
 
+// WordsPerMinute is the reading speed that ReadingTime is estimated with.
+const WordsPerMinute = 200
+
 type FrontMatter struct {
-	Title     string     `json:"title"`
-	CreatedAt time.Time  `json:"createdAt"`
-	UpdatedAt *time.Time `json:"updatedAt"`
-	Template  string     `json:"template"`
-	UglyURL   bool       `json:"uglyURL"`
+	Title      string     `json:"title"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	UpdatedAt  *time.Time `json:"updatedAt"`
+	ExpiresAt  *time.Time `json:"expiresAt"`
+	Template   string     `json:"template"`
+	UglyURL    bool       `json:"uglyURL"`
+	Draft      bool       `json:"draft"`
+	NoSitemap  bool       `json:"noSitemap"`
+	Tags       []string   `json:"tags"`
+	Categories []string   `json:"categories"`
+	// Params holds every other front matter field, keyed as written.
+	Params map[string]any `json:"-"`
+}
+
+// frontMatterFields are the JSON names of the fields of FrontMatter,
+// timeFields those of its time.Time and *time.Time fields, and stringFields
+// those of its string and []string fields.
+var frontMatterFields, timeFields, stringFields = func() ([]string, []string, []string) {
+	t := reflect.TypeFor[FrontMatter]()
+	fields := make([]string, 0, t.NumField())
+	times := []string{}
+	strs := []string{}
+	for i := range t.NumField() {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" || name == "-" {
+			continue
+		}
+		fields = append(fields, name)
+		switch field.Type {
+		case reflect.TypeFor[time.Time](), reflect.TypeFor[*time.Time]():
+			times = append(times, name)
+		case reflect.TypeFor[string](), reflect.TypeFor[[]string]():
+			strs = append(strs, name)
+		}
+	}
+	return fields, times, strs
+}()
+
+// params returns the fields of frontMatter that are not fields of FrontMatter,
+// or nil if there are none. Like encoding/json, it matches names regardless of
+// case.
+func params(frontMatter []byte) (map[string]any, error) {
+	fields := map[string]any{}
+	if err := json.Unmarshal(frontMatter, &fields); err != nil {
+		return nil, err
+	}
+	for key := range fields {
+		for _, name := range frontMatterFields {
+			if strings.EqualFold(key, name) {
+				delete(fields, key)
+				break
+			}
+		}
+	}
+	if len(fields) == 0 {
+		return nil, nil
+	}
+	return fields, nil
 }
 
 func (fm *FrontMatter) validate() error {
@@ -37,18 +121,63 @@ func (fm *FrontMatter) validate() error {
 	if fm.CreatedAt.IsZero() {
 		missingFields = append(missingFields, "created-at")
 	}
-	if fm.Template == "" {
-		missingFields = append(missingFields, "template")
-	}
 	if len(missingFields) > 0 {
 		return fmt.Errorf(
 			"missing required front matter fields: %s",
 			strings.Join(missingFields, ", "),
 		)
 	}
+	if fm.ExpiresAt != nil && !fm.ExpiresAt.After(fm.CreatedAt) {
+		return fmt.Errorf("expiresAt must be after createdAt")
+	}
 	return nil
 }
 
+// PositionError is an error found at a specific line and column of a
+// markdown file. Path is left empty by Parse, which does not know which file
+// it is reading.
+type PositionError struct {
+	Path   string
+	Line   int
+	Column int
+	Err    error
+}
+
+func (e *PositionError) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("%d:%d: %s", e.Line, e.Column, e.Err)
+	}
+	return fmt.Sprintf("%s:%d:%d: %s", e.Path, e.Line, e.Column, e.Err)
+}
+
+func (e *PositionError) Unwrap() error {
+	return e.Err
+}
+
+// frontMatterError locates err within the front matter when encoding/json
+// reports the byte offset it failed at.
+func frontMatterError(frontMatter []byte, err error) error {
+	var offset int64
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		offset = syntaxErr.Offset
+	case errors.As(err, &typeErr):
+		offset = typeErr.Offset
+	default:
+		return err
+	}
+	offset = min(offset, int64(len(frontMatter)))
+	prefix := frontMatter[:offset]
+	return &PositionError{
+		// Front matter starts on the line after the opening delimiter.
+		Line:   bytes.Count(prefix, []byte{'\n'}) + 2,
+		Column: len(prefix) - bytes.LastIndexByte(prefix, '\n') - 1,
+		Err:    err,
+	}
+}
+
 type externalLinkTransformer struct{}
 
 func (t *externalLinkTransformer) Transform(
@@ -57,31 +186,132 @@ func (t *externalLinkTransformer) Transform(
 	pc parser.Context,
 ) {
 	_ = ast.Walk(node, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
-		if !entering || n.Kind() != ast.KindLink {
+		if !entering {
 			return ast.WalkContinue, nil
 		}
 
-		link := n.(*ast.Link)
-		dest := string(link.Destination)
+		var dest string
+		switch link := n.(type) {
+		case *ast.Link:
+			dest = string(link.Destination)
+		case *ast.AutoLink:
+			dest = string(link.URL(reader.Source()))
+		default:
+			return ast.WalkContinue, nil
+		}
 
 		if strings.HasPrefix(dest, "http://") || strings.HasPrefix(dest, "https://") {
-			link.SetAttribute([]byte("target"), []byte("_blank"))
-			link.SetAttribute([]byte("rel"), []byte("noopener noreferrer"))
+			n.SetAttribute([]byte("target"), []byte("_blank"))
+			n.SetAttribute([]byte("rel"), []byte("noopener noreferrer"))
 		}
 
 		return ast.WalkContinue, nil
 	})
 }
 
-var markdown = goldmark.New(
-	goldmark.WithParserOptions(
-		parser.WithASTTransformers(
-			util.Prioritized(&externalLinkTransformer{}, 100),
+// Options change how markdown is rendered to HTML.
+type Options struct {
+	// Unsafe renders raw HTML and links with dangerous URLs, such as
+	// javascript: links, rather than leaving them out.
+	Unsafe bool
+	// HardWraps renders line breaks within paragraphs as <br> tags.
+	HardWraps  bool
+	Extensions Extensions
+	Highlight  Highlight
+	TOC        TOC
+	// SummaryLength is the number of words of summaries cut from the text of
+	// pages. When zero, DefaultSummaryLength is used.
+	SummaryLength int
+}
+
+// Extensions enable goldmark extensions that add syntax to CommonMark.
+type Extensions struct {
+	// Tables, Strikethrough, TaskLists and Autolinks are the additions of
+	// GitHub Flavored Markdown.
+	Tables        bool
+	Strikethrough bool
+	TaskLists     bool
+	// Autolinks turns URLs and email addresses in text into links.
+	Autolinks       bool
+	Footnotes       bool
+	DefinitionLists bool
+	// Typographer replaces quotes, dashes and ellipses with their
+	// typographic equivalents, e.g. "--" with an en dash.
+	Typographer bool
+}
+
+func (e Extensions) extenders() []goldmark.Extender {
+	enabled := []struct {
+		on       bool
+		extender goldmark.Extender
+	}{
+		{e.Tables, extension.Table},
+		{e.Strikethrough, extension.Strikethrough},
+		{e.TaskLists, extension.TaskList},
+		{e.Autolinks, extension.Linkify},
+		{e.Footnotes, extension.Footnote},
+		{e.DefinitionLists, extension.DefinitionList},
+		{e.Typographer, extension.Typographer},
+	}
+	extenders := []goldmark.Extender{}
+	for _, ext := range enabled {
+		if ext.on {
+			extenders = append(extenders, ext.extender)
+		}
+	}
+	return extenders
+}
+
+// Markdown parses markdown files with a fixed set of Options. Each project
+// gets its own, since projects choose which syntax they use.
+type Markdown struct {
+	goldmark      goldmark.Markdown
+	summaryLength int
+}
+
+func New(opts Options) *Markdown {
+	rendererOptions := []renderer.Option{}
+	if opts.Unsafe {
+		rendererOptions = append(rendererOptions, html.WithUnsafe())
+	}
+	if opts.HardWraps {
+		rendererOptions = append(rendererOptions, html.WithHardWraps())
+	}
+	headings := &headingTransformer{
+		minLevel: cmp.Or(opts.TOC.MinLevel, 1),
+		maxLevel: cmp.Or(opts.TOC.MaxLevel, 6),
+	}
+	transformers := []util.PrioritizedValue{
+		util.Prioritized(&externalLinkTransformer{}, 100),
+		util.Prioritized(headings, 100),
+		util.Prioritized(&contentLinkTransformer{}, 100),
+	}
+	if opts.Highlight.Enabled {
+		rendererOptions = append(rendererOptions, renderer.WithNodeRenderers(
+			util.Prioritized(&codeBlockRenderer{lineNumbers: opts.Highlight.LineNumbers}, 100),
+		))
+		transformers = append(transformers, util.Prioritized(
+			&codeBlockTransformer{lineNumbers: opts.Highlight.LineNumbers},
+			100,
+		))
+	}
+	return &Markdown{
+		goldmark: goldmark.New(
+			goldmark.WithParserOptions(parser.WithASTTransformers(transformers...)),
+			goldmark.WithRendererOptions(rendererOptions...),
+			goldmark.WithExtensions(opts.Extensions.extenders()...),
 		),
-	),
-)
+		summaryLength: cmp.Or(opts.SummaryLength, DefaultSummaryLength),
+	}
+}
 
-func Parse(reader io.Reader) (*ParsedFile, error) {
+func (m *Markdown) Parse(reader io.Reader) (*ParsedFile, error) {
+	return m.ParseWithURLs(reader, nil)
+}
+
+// ParseWithURLs is Parse, but points the links to markdown files whose
+// destination, as written, is a key of urls at the URL it maps to.
+func (m *Markdown) ParseWithURLs(reader io.Reader, urls map[string]string) (*ParsedFile, error) {
 	pf, err := readPageFile(reader)
 	if err != nil {
 		return nil, err
@@ -89,27 +319,216 @@ func Parse(reader io.Reader) (*ParsedFile, error) {
 
 	parsedFile := &ParsedFile{}
 
-	if err := json.Unmarshal(pf.frontMatter, &parsedFile.FrontMatter); err != nil {
+	frontMatter, keys, err := pf.frontMatterJSON()
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(frontMatter, &parsedFile.FrontMatter); err != nil {
+		layouts := jsonTimeLayouts
+		if keys != nil {
+			layouts = timeLayouts
+		}
+		err = timeFieldError(frontMatter, layouts, err)
+		if keys != nil {
+			return nil, keyError(keys, err)
+		}
+		return nil, frontMatterError(frontMatter, err)
+	}
+	parsedFile.FrontMatter.Params, err = params(frontMatter)
+	if err != nil {
 		return nil, err
 	}
 	if err := parsedFile.FrontMatter.validate(); err != nil {
 		return nil, err
 	}
 
-	buf := &bytes.Buffer{}
-	if err := markdown.Convert(pf.content, buf); err != nil {
+	err = m.render(pf.content, parsedFile, urls)
+	var posErr *PositionError
+	if errors.As(err, &posErr) {
+		posErr.Line += pf.contentLine - 1
+	}
+	if err != nil {
 		return nil, err
 	}
-	parsedFile.HTML = buf.String()
+	for i := range parsedFile.Links {
+		parsedFile.Links[i].Line += pf.contentLine - 1
+	}
+	for _, err := range parsedFile.Warnings {
+		if errors.As(err, &posErr) {
+			posErr.Line += pf.contentLine - 1
+		}
+	}
 
 	return parsedFile, nil
 }
 
-var frontMatterDelimiter = []byte{'-', '-', '-'}
+// render sets the HTML, table of contents, summary, links and text of
+// parsedFile from content. A <!--more--> marker is left out of the HTML, and the content
+// before it is the summary. Without a marker, the summary is the summary or
+// description in the front matter, or else the first words of the text.
+func (m *Markdown) render(content []byte, parsedFile *ParsedFile, urls map[string]string) error {
+	pc := parser.NewContext()
+	pc.Set(linkURLsKey, urls)
+	doc := m.goldmark.Parser().Parse(text.NewReader(content), parser.WithContext(pc))
+	parsedFile.TOC, _ = pc.Get(tocKey).([]Heading)
+	parsedFile.TOCHTML = tocHTML(parsedFile.TOC)
+	links, _ := pc.Get(linksKey).([]link)
+	for _, l := range links {
+		l.Line, l.Column = offsetPosition(content, l.offset)
+		parsedFile.Links = append(parsedFile.Links, l.Link)
+	}
+	parsedFile.Warnings, _ = pc.Get(codeBlockErrorsKey).([]error)
+	parsedFile.Plain = plainText(doc, content)
+	parsedFile.WordCount = len(strings.Fields(parsedFile.Plain))
+	parsedFile.ReadingTime = (parsedFile.WordCount + WordsPerMinute - 1) / WordsPerMinute
+	more, hasMore := cutMore(doc, content)
+
+	var err error
+	if parsedFile.HTML, err = m.renderHTML(content, doc); err != nil {
+		return err
+	}
+	if hasMore {
+		removeFrom(more)
+		parsedFile.Summary, err = m.renderHTML(content, doc)
+		return err
+	}
+	if summary, ok := frontMatterSummary(parsedFile.FrontMatter.Params); ok {
+		parsedFile.Summary = summary
+		return nil
+	}
+	parsedFile.Summary = textSummary(parsedFile.Plain, m.summaryLength)
+	return nil
+}
+
+// ToHTML renders markdown without front matter to HTML. The HTML is trusted
+// so that templates do not escape it again: goldmark already escapes text and
+// leaves out raw HTML unless the project opted into Options.Unsafe.
+func (m *Markdown) ToHTML(src []byte) (template.HTML, error) {
+	doc := m.goldmark.Parser().Parse(text.NewReader(src))
+	return m.renderHTML(src, doc)
+}
+
+func (m *Markdown) renderHTML(src []byte, doc ast.Node) (template.HTML, error) {
+	buf := &bytes.Buffer{}
+	if err := m.goldmark.Renderer().Render(buf, src, doc); err != nil {
+		return "", err
+	}
+	return template.HTML(buf.String()), nil //nolint:gosec // See ToHTML.
+}
+
+var (
+	frontMatterDelimiter     = []byte{'-', '-', '-'}
+	tomlFrontMatterDelimiter = []byte{'+', '+', '+'}
+)
 
 type rawFile struct {
+	// toml is set when the front matter is delimited by +++ rather than ---.
+	toml        bool
 	frontMatter []byte
 	content     []byte
+	// contentLine is the line of the file that content starts on.
+	contentLine int
+}
+
+// frontMatterJSON returns the front matter as JSON. Front matter between ---
+// lines is JSON if it starts with '{', and YAML otherwise. For YAML and TOML,
+// it also returns where each top-level key is, since errors found decoding
+// the JSON can't be located in the front matter by their offset.
+func (pf *rawFile) frontMatterJSON() ([]byte, map[string]position, error) {
+	var fields map[string]any
+	var keys map[string]position
+	var err error
+	switch {
+	case pf.toml:
+		fields, keys, err = parseTOML(pf.frontMatter)
+	case bytes.HasPrefix(bytes.TrimSpace(pf.frontMatter), []byte{'{'}):
+		return pf.frontMatter, nil, nil
+	default:
+		fields, keys, err = parseYAML(pf.frontMatter, stringFields...)
+	}
+	var posErr *PositionError
+	if errors.As(err, &posErr) {
+		// Front matter starts on the line after the opening delimiter.
+		posErr.Line++
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	frontMatter, err := json.Marshal(fields)
+	if err != nil {
+		return nil, nil, err
+	}
+	return frontMatter, keys, nil
+}
+
+// fieldError is an error decoding a field of FrontMatter that encoding/json
+// does not name the field of.
+type fieldError struct {
+	field string
+	err   error
+}
+
+func (e *fieldError) Error() string {
+	return e.field + ": " + e.err.Error()
+}
+
+func (e *fieldError) Unwrap() error {
+	return e.err
+}
+
+// jsonTimeLayouts describes the times JSON front matter accepts, and
+// timeLayouts those of YAML and TOML front matter.
+const (
+	jsonTimeLayouts = "RFC 3339 such as 2006-01-02T15:04:05Z or 2006-01-02T15:04:05+02:00"
+	timeLayouts     = jsonTimeLayouts + ", or unquoted 2006-01-02 or 2006-01-02 15:04:05"
+)
+
+// timeFieldError names the time field of frontMatter that err was found
+// decoding, since time.Time reports the value it could not parse but not the
+// field it was in, and lists the layouts that would have been accepted.
+func timeFieldError(frontMatter []byte, layouts string, err error) error {
+	var parseErr *time.ParseError
+	if !errors.As(err, &parseErr) {
+		return err
+	}
+	fields := map[string]json.RawMessage{}
+	if json.Unmarshal(frontMatter, &fields) != nil {
+		return err
+	}
+	for key, value := range fields {
+		isTime := slices.ContainsFunc(timeFields, func(name string) bool {
+			return strings.EqualFold(key, name)
+		})
+		var t time.Time
+		if isTime && json.Unmarshal(value, &t) != nil {
+			return &fieldError{
+				field: key,
+				err:   fmt.Errorf("invalid time %q, expected %s", parseErr.Value, layouts),
+			}
+		}
+	}
+	return err
+}
+
+// keyError locates err at the top-level key it was found in.
+func keyError(keys map[string]position, err error) error {
+	var field string
+	var typeErr *json.UnmarshalTypeError
+	var fieldErr *fieldError
+	switch {
+	case errors.As(err, &typeErr):
+		field, _, _ = strings.Cut(typeErr.Field, ".")
+	case errors.As(err, &fieldErr):
+		field = fieldErr.field
+	default:
+		return err
+	}
+	for key, pos := range keys {
+		if strings.EqualFold(key, field) {
+			return &PositionError{Line: pos.line + 1, Column: pos.column, Err: err}
+		}
+	}
+	return err
 }
 
 func readPageFile(reader io.Reader) (*rawFile, error) {
@@ -118,14 +537,23 @@ func readPageFile(reader io.Reader) (*rawFile, error) {
 
 	scanner := bufio.NewScanner(reader)
 	scanner.Scan()
-	firstLine := scanner.Bytes()
-	inFrontMatter := bytes.Equal(firstLine, frontMatterDelimiter)
+	delimiter := slices.Clone(scanner.Bytes())
+	isTOML := bytes.Equal(delimiter, tomlFrontMatterDelimiter)
+	inFrontMatter := isTOML || bytes.Equal(delimiter, frontMatterDelimiter)
 	if !inFrontMatter {
-		return nil, fmt.Errorf("could not find front matter")
+		return nil, &PositionError{
+			Line:   1,
+			Column: 1,
+			Err:    errors.New("could not find front matter"),
+		}
 	}
+	contentLine := 2
 	for scanner.Scan() {
 		line := scanner.Bytes()
-		if inFrontMatter && bytes.Equal(line, frontMatterDelimiter) {
+		if inFrontMatter {
+			contentLine++
+		}
+		if inFrontMatter && bytes.Equal(line, delimiter) {
 			inFrontMatter = false
 			continue
 		}
@@ -140,8 +568,10 @@ func readPageFile(reader io.Reader) (*rawFile, error) {
 		return nil, err
 	}
 	return &rawFile{
+		toml:        isTOML,
 		frontMatter: frontMatter,
 		content:     content,
+		contentLine: contentLine,
 	}, nil
 }
 
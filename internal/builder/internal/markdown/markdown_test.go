@@ -1,6 +1,9 @@
 package markdown_test
 
 import (
+	"errors"
+	"html/template"
+	"maps"
 	"reflect"
 	"strings"
 	"testing"
@@ -10,6 +13,15 @@ import (
 	"github.com/fivethirty/satisficer/internal/testutil"
 )
 
+// testContentTOC and testContentTOCHTML are the table of contents of
+// "# Test Content".
+var (
+	testContentTOC     = []markdown.Heading{{Level: 1, ID: "test-content", Title: "Test Content"}}
+	testContentTOCHTML = template.HTML(
+		`<nav class="toc"><ul><li><a href="#test-content">Test Content</a></li></ul></nav>` + "\n",
+	)
+)
+
 func TestParse(t *testing.T) {
 	t.Parallel()
 
@@ -37,7 +49,13 @@ func TestParse(t *testing.T) {
 					UpdatedAt: nil,
 					Template:  "page.html.tmpl",
 				},
-				HTML: "<h1>Test Content</h1>\n",
+				HTML:        `<h1 id="test-content">Test Content</h1>` + "\n",
+				TOC:         testContentTOC,
+				TOCHTML:     testContentTOCHTML,
+				Summary:     "Test Content",
+				Plain:       "Test Content",
+				WordCount:   2,
+				ReadingTime: 1,
 			},
 		},
 		{
@@ -59,7 +77,45 @@ func TestParse(t *testing.T) {
 					UpdatedAt: testutil.Ptr(t, time.Date(2025, 5, 14, 0, 0, 0, 0, time.UTC)),
 					Template:  "page.html.tmpl",
 				},
-				HTML: "<h1>Test Content</h1>\n",
+				HTML:        `<h1 id="test-content">Test Content</h1>` + "\n",
+				TOC:         testContentTOC,
+				TOCHTML:     testContentTOCHTML,
+				Summary:     "Test Content",
+				Plain:       "Test Content",
+				WordCount:   2,
+				ReadingTime: 1,
+			},
+		},
+		{
+			name: "keeps unknown front matter fields as params",
+			markdown: testutil.ToContent(
+				t,
+				map[string]any{
+					"title":     "Test Title",
+					"createdAt": "2025-05-13T00:00:00Z",
+					"Template":  "page.html.tmpl",
+					"author":    "Jane",
+					"hero":      map[string]any{"src": "hero.png", "width": 800},
+				},
+				"# Test Content",
+			),
+			wantPage: &markdown.ParsedFile{
+				FrontMatter: markdown.FrontMatter{
+					Title:     "Test Title",
+					CreatedAt: time.Date(2025, 5, 13, 0, 0, 0, 0, time.UTC),
+					Template:  "page.html.tmpl",
+					Params: map[string]any{
+						"author": "Jane",
+						"hero":   map[string]any{"src": "hero.png", "width": float64(800)},
+					},
+				},
+				HTML:        `<h1 id="test-content">Test Content</h1>` + "\n",
+				TOC:         testContentTOC,
+				TOCHTML:     testContentTOCHTML,
+				Summary:     "Test Content",
+				Plain:       "Test Content",
+				WordCount:   2,
+				ReadingTime: 1,
 			},
 		},
 		{
@@ -109,6 +165,30 @@ func TestParse(t *testing.T) {
 			),
 			wantError: true,
 		},
+		{
+			name: "can load a page without a template in front matter",
+			markdown: testutil.ToContent(
+				t,
+				map[string]any{
+					"title":     "Test Title",
+					"createdAt": "2025-05-13T00:00:00Z",
+				},
+				"# Test Content",
+			),
+			wantPage: &markdown.ParsedFile{
+				FrontMatter: markdown.FrontMatter{
+					Title:     "Test Title",
+					CreatedAt: time.Date(2025, 5, 13, 0, 0, 0, 0, time.UTC),
+				},
+				HTML:        `<h1 id="test-content">Test Content</h1>` + "\n",
+				TOC:         testContentTOC,
+				TOCHTML:     testContentTOCHTML,
+				Summary:     "Test Content",
+				Plain:       "Test Content",
+				WordCount:   2,
+				ReadingTime: 1,
+			},
+		},
 		{
 			name: "external HTTP link gets target=_blank",
 			markdown: testutil.ToContent(
@@ -127,7 +207,11 @@ func TestParse(t *testing.T) {
 					UpdatedAt: nil,
 					Template:  "page.html.tmpl",
 				},
-				HTML: `<p><a href="http://example.com" target="_blank" rel="noopener noreferrer">Example</a></p>` + "\n",
+				HTML:        `<p><a href="http://example.com" target="_blank" rel="noopener noreferrer">Example</a></p>` + "\n",
+				Summary:     "Example",
+				Plain:       "Example",
+				WordCount:   1,
+				ReadingTime: 1,
 			},
 		},
 		{
@@ -148,7 +232,11 @@ func TestParse(t *testing.T) {
 					UpdatedAt: nil,
 					Template:  "page.html.tmpl",
 				},
-				HTML: `<p><a href="https://example.com" target="_blank" rel="noopener noreferrer">Example</a></p>` + "\n",
+				HTML:        `<p><a href="https://example.com" target="_blank" rel="noopener noreferrer">Example</a></p>` + "\n",
+				Summary:     "Example",
+				Plain:       "Example",
+				WordCount:   1,
+				ReadingTime: 1,
 			},
 		},
 		{
@@ -169,7 +257,11 @@ func TestParse(t *testing.T) {
 					UpdatedAt: nil,
 					Template:  "page.html.tmpl",
 				},
-				HTML: `<p><a href="/about">about page</a></p>` + "\n",
+				HTML:        `<p><a href="/about">about page</a></p>` + "\n",
+				Summary:     "about page",
+				Plain:       "about page",
+				WordCount:   2,
+				ReadingTime: 1,
 			},
 		},
 		{
@@ -190,7 +282,11 @@ func TestParse(t *testing.T) {
 					UpdatedAt: nil,
 					Template:  "page.html.tmpl",
 				},
-				HTML: `<p><a href="#heading">section</a></p>` + "\n",
+				HTML:        `<p><a href="#heading">section</a></p>` + "\n",
+				Summary:     "section",
+				Plain:       "section",
+				WordCount:   1,
+				ReadingTime: 1,
 			},
 		},
 		{
@@ -213,15 +309,150 @@ func TestParse(t *testing.T) {
 					Template:  "page.html.tmpl",
 					UglyURL:   true,
 				},
-				HTML: "<h1>Test Content</h1>\n",
+				HTML:        `<h1 id="test-content">Test Content</h1>` + "\n",
+				TOC:         testContentTOC,
+				TOCHTML:     testContentTOCHTML,
+				Summary:     "Test Content",
+				Plain:       "Test Content",
+				WordCount:   2,
+				ReadingTime: 1,
+			},
+		},
+		{
+			name: "can load a page marked as a draft",
+			markdown: testutil.ToContent(
+				t,
+				map[string]any{
+					"title":     "Test Title",
+					"createdAt": "2025-05-13T00:00:00Z",
+					"template":  "page.html.tmpl",
+					"draft":     true,
+				},
+				"# Test Content",
+			),
+			wantPage: &markdown.ParsedFile{
+				FrontMatter: markdown.FrontMatter{
+					Title:     "Test Title",
+					CreatedAt: time.Date(2025, 5, 13, 0, 0, 0, 0, time.UTC),
+					UpdatedAt: nil,
+					Template:  "page.html.tmpl",
+					Draft:     true,
+				},
+				HTML:        `<h1 id="test-content">Test Content</h1>` + "\n",
+				TOC:         testContentTOC,
+				TOCHTML:     testContentTOCHTML,
+				Summary:     "Test Content",
+				Plain:       "Test Content",
+				WordCount:   2,
+				ReadingTime: 1,
+			},
+		},
+		{
+			name: "can load a page excluded from the sitemap",
+			markdown: testutil.ToContent(
+				t,
+				map[string]any{
+					"title":     "Test Title",
+					"createdAt": "2025-05-13T00:00:00Z",
+					"template":  "page.html.tmpl",
+					"noSitemap": true,
+				},
+				"# Test Content",
+			),
+			wantPage: &markdown.ParsedFile{
+				FrontMatter: markdown.FrontMatter{
+					Title:     "Test Title",
+					CreatedAt: time.Date(2025, 5, 13, 0, 0, 0, 0, time.UTC),
+					Template:  "page.html.tmpl",
+					NoSitemap: true,
+				},
+				HTML:        `<h1 id="test-content">Test Content</h1>` + "\n",
+				TOC:         testContentTOC,
+				TOCHTML:     testContentTOCHTML,
+				Summary:     "Test Content",
+				Plain:       "Test Content",
+				WordCount:   2,
+				ReadingTime: 1,
 			},
 		},
+		{
+			name: "can load a page with tags and categories",
+			markdown: testutil.ToContent(
+				t,
+				map[string]any{
+					"title":      "Test Title",
+					"createdAt":  "2025-05-13T00:00:00Z",
+					"template":   "page.html.tmpl",
+					"tags":       []string{"go", "static sites"},
+					"categories": []string{"Notes"},
+				},
+				"# Test Content",
+			),
+			wantPage: &markdown.ParsedFile{
+				FrontMatter: markdown.FrontMatter{
+					Title:      "Test Title",
+					CreatedAt:  time.Date(2025, 5, 13, 0, 0, 0, 0, time.UTC),
+					Template:   "page.html.tmpl",
+					Tags:       []string{"go", "static sites"},
+					Categories: []string{"Notes"},
+				},
+				HTML:        `<h1 id="test-content">Test Content</h1>` + "\n",
+				TOC:         testContentTOC,
+				TOCHTML:     testContentTOCHTML,
+				Summary:     "Test Content",
+				Plain:       "Test Content",
+				WordCount:   2,
+				ReadingTime: 1,
+			},
+		},
+		{
+			name: "can load a page with an expiry date",
+			markdown: testutil.ToContent(
+				t,
+				map[string]any{
+					"title":     "Test Title",
+					"createdAt": "2025-05-13T00:00:00Z",
+					"expiresAt": "2025-06-13T00:00:00Z",
+					"template":  "page.html.tmpl",
+				},
+				"# Test Content",
+			),
+			wantPage: &markdown.ParsedFile{
+				FrontMatter: markdown.FrontMatter{
+					Title:     "Test Title",
+					CreatedAt: time.Date(2025, 5, 13, 0, 0, 0, 0, time.UTC),
+					ExpiresAt: testutil.Ptr(t, time.Date(2025, 6, 13, 0, 0, 0, 0, time.UTC)),
+					Template:  "page.html.tmpl",
+				},
+				HTML:        `<h1 id="test-content">Test Content</h1>` + "\n",
+				TOC:         testContentTOC,
+				TOCHTML:     testContentTOCHTML,
+				Summary:     "Test Content",
+				Plain:       "Test Content",
+				WordCount:   2,
+				ReadingTime: 1,
+			},
+		},
+		{
+			name: "can't load a page that expires before it is created",
+			markdown: testutil.ToContent(
+				t,
+				map[string]any{
+					"title":     "Test Title",
+					"createdAt": "2025-05-13T00:00:00Z",
+					"expiresAt": "2025-04-13T00:00:00Z",
+					"template":  "page.html.tmpl",
+				},
+				"# Test Content",
+			),
+			wantError: true,
+		},
 	}
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			t.Parallel()
-			p, err := markdown.Parse(strings.NewReader(test.markdown))
+			p, err := markdown.New(markdown.Options{}).Parse(strings.NewReader(test.markdown))
 			if err != nil {
 				if !test.wantError {
 					t.Fatalf("unexpected error: %v", err)
@@ -237,3 +468,513 @@ func TestParse(t *testing.T) {
 		})
 	}
 }
+
+func TestParse_ErrorPosition(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		opts       markdown.Options
+		markdown   string
+		wantLine   int
+		wantColumn int
+	}{
+		{
+			name:       "missing front matter",
+			markdown:   "# Test Content",
+			wantLine:   1,
+			wantColumn: 1,
+		},
+		{
+			name:       "syntax error on first line of front matter",
+			markdown:   "---\n{invalid}\n---\n# Test Content",
+			wantLine:   2,
+			wantColumn: 2,
+		},
+		{
+			name:       "syntax error on later line of front matter",
+			markdown:   "---\n{\n  \"title\": \"Test\",\n  oops\n}\n---\n# Test Content",
+			wantLine:   4,
+			wantColumn: 3,
+		},
+		{
+			name:       "wrong type in front matter",
+			markdown:   "---\n{\n  \"uglyURL\": \"yes\"\n}\n---\n# Test Content",
+			wantLine:   3,
+			wantColumn: 18,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := markdown.New(test.opts).Parse(strings.NewReader(test.markdown))
+			var posErr *markdown.PositionError
+			if !errors.As(err, &posErr) {
+				t.Fatalf("expected a PositionError, got %v", err)
+			}
+			if posErr.Line != test.wantLine || posErr.Column != test.wantColumn {
+				t.Fatalf(
+					"expected position %d:%d, got %d:%d",
+					test.wantLine,
+					test.wantColumn,
+					posErr.Line,
+					posErr.Column,
+				)
+			}
+		})
+	}
+}
+
+func TestParse_CodeBlockWarnings(t *testing.T) {
+	t.Parallel()
+
+	md := markdown.New(markdown.Options{Highlight: markdown.Highlight{Enabled: true}})
+	p, err := md.Parse(strings.NewReader(
+		"---\ntitle: Test\ncreatedAt: 2025-05-13T00:00:00Z\n---\n# Test Content\n\n" +
+			"```go {highlight=5}\nreturn\n```\n\n```{bogus}\nx\n```\n\n```go {start=2}\ny\n```\n",
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"7:4: cannot highlight line 5 of 1",
+		`11:4: unknown option "bogus"`,
+	}
+	got := []string{}
+	for _, warning := range p.Warnings {
+		got = append(got, warning.Error())
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected warnings %q, got %q", want, got)
+	}
+	if !strings.Contains(string(p.HTML), "<pre><code class=\"language-go\">return\n</code></pre>") {
+		t.Fatalf("expected the code block with invalid options unhighlighted, got %q", p.HTML)
+	}
+}
+
+func TestParse_TOC(t *testing.T) {
+	t.Parallel()
+
+	content := "# Guide\n\n## Getting *started*\n\n### Install `go`\n\n#### Details\n\n" +
+		"## Getting started\n\n## Über uns\n\n## !!!\n"
+	tests := []struct {
+		name        string
+		opts        markdown.Options
+		wantTOC     []markdown.Heading
+		wantTOCHTML template.HTML
+	}{
+		{
+			name: "lists every heading by default",
+			wantTOC: []markdown.Heading{{
+				Level: 1, ID: "guide", Title: "Guide", Children: []markdown.Heading{
+					{
+						Level: 2, ID: "getting-started", Title: "Getting started",
+						Children: []markdown.Heading{{
+							Level: 3, ID: "install-go", Title: "Install go",
+							Children: []markdown.Heading{
+								{Level: 4, ID: "details", Title: "Details"},
+							},
+						}},
+					},
+					{Level: 2, ID: "getting-started-1", Title: "Getting started"},
+					{Level: 2, ID: "über-uns", Title: "Über uns"},
+					{Level: 2, ID: "heading", Title: "!!!"},
+				},
+			}},
+			wantTOCHTML: `<nav class="toc"><ul><li><a href="#guide">Guide</a><ul>` +
+				`<li><a href="#getting-started">Getting started</a><ul>` +
+				`<li><a href="#install-go">Install go</a><ul>` +
+				`<li><a href="#details">Details</a></li></ul></li></ul></li>` +
+				`<li><a href="#getting-started-1">Getting started</a></li>` +
+				`<li><a href="#über-uns">Über uns</a></li>` +
+				`<li><a href="#heading">!!!</a></li></ul></li></ul></nav>` + "\n",
+		},
+		{
+			name: "lists headings within the levels",
+			opts: markdown.Options{TOC: markdown.TOC{MinLevel: 2, MaxLevel: 3}},
+			wantTOC: []markdown.Heading{
+				{
+					Level: 2, ID: "getting-started", Title: "Getting started",
+					Children: []markdown.Heading{{Level: 3, ID: "install-go", Title: "Install go"}},
+				},
+				{Level: 2, ID: "getting-started-1", Title: "Getting started"},
+				{Level: 2, ID: "über-uns", Title: "Über uns"},
+				{Level: 2, ID: "heading", Title: "!!!"},
+			},
+			wantTOCHTML: `<nav class="toc"><ul>` +
+				`<li><a href="#getting-started">Getting started</a><ul>` +
+				`<li><a href="#install-go">Install go</a></li></ul></li>` +
+				`<li><a href="#getting-started-1">Getting started</a></li>` +
+				`<li><a href="#über-uns">Über uns</a></li>` +
+				`<li><a href="#heading">!!!</a></li></ul></nav>` + "\n",
+		},
+		{
+			name: "lists nothing outside of the levels",
+			opts: markdown.Options{TOC: markdown.TOC{MinLevel: 5}},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			page := testutil.ToContent(
+				t,
+				map[string]any{"title": "Guide", "createdAt": "2025-05-13T00:00:00Z"},
+				content,
+			)
+			p, err := markdown.New(test.opts).Parse(strings.NewReader(page))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(p.TOC, test.wantTOC) {
+				t.Fatalf("expected TOC %+v, got %+v", test.wantTOC, p.TOC)
+			}
+			if p.TOCHTML != test.wantTOCHTML {
+				t.Fatalf("expected TOC HTML %q, got %q", test.wantTOCHTML, p.TOCHTML)
+			}
+			wantHeading := `<h2 id="getting-started-1">Getting started</h2>`
+			if !strings.Contains(string(p.HTML), wantHeading) {
+				t.Fatalf("expected %q in %q", wantHeading, p.HTML)
+			}
+		})
+	}
+}
+
+func TestSlugify(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		want string
+	}{
+		{name: "go", want: "go"},
+		{name: "Go", want: "go"},
+		{name: "Static Sites", want: "static-sites"},
+		{name: "  C++ & Rust!  ", want: "c-rust"},
+		{name: "año-2025", want: "año-2025"},
+		{name: "!!!", want: ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			if got := markdown.Slugify(test.name); got != test.want {
+				t.Fatalf("expected %q, got %q", test.want, got)
+			}
+		})
+	}
+}
+
+func TestParse_Summary(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		opts        markdown.Options
+		frontMatter map[string]any
+		markdown    string
+		wantHTML    template.HTML
+		wantSummary template.HTML
+	}{
+		{
+			name:        "cuts the summary at the more marker",
+			markdown:    "Intro *text*.\n\n<!--more-->\n\nThe rest.",
+			wantHTML:    "<p>Intro <em>text</em>.</p>\n<p>The rest.</p>\n",
+			wantSummary: "<p>Intro <em>text</em>.</p>\n",
+		},
+		{
+			name:        "leaves out the more marker when unsafe",
+			opts:        markdown.Options{Unsafe: true},
+			markdown:    "Intro.\n<!--more-->\nThe rest.\n<!-- note -->",
+			wantHTML:    "<p>Intro.</p>\n<p>The rest.</p>\n<!-- note -->\n",
+			wantSummary: "<p>Intro.</p>\n",
+		},
+		{
+			name:        "ignores more markers in code",
+			frontMatter: map[string]any{"description": "A <b>page</b>."},
+			markdown:    "```\n<!--more-->\n```",
+			wantHTML:    "<pre><code>&lt;!--more--&gt;\n</code></pre>\n",
+			wantSummary: "A &lt;b&gt;page&lt;/b&gt;.",
+		},
+		{
+			name: "prefers the summary to the description",
+			frontMatter: map[string]any{
+				"summary":     "The summary.",
+				"description": "The description.",
+			},
+			markdown:    "Text.",
+			wantHTML:    "<p>Text.</p>\n",
+			wantSummary: "The summary.",
+		},
+		{
+			name:     "cuts the summary from the text",
+			opts:     markdown.Options{SummaryLength: 5},
+			markdown: "# Title\n\nOne *two* three\nfour.\n\n- five\n- six",
+			wantHTML: "<h1 id=\"title\">Title</h1>\n<p>One <em>two</em> three\nfour.</p>\n" +
+				"<ul>\n<li>five</li>\n<li>six</li>\n</ul>\n",
+			wantSummary: "Title One two three four.…",
+		},
+		{
+			name:        "uses the whole text when it is short",
+			markdown:    "Tom & Jerry",
+			wantHTML:    "<p>Tom &amp; Jerry</p>\n",
+			wantSummary: "Tom &amp; Jerry",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			frontMatter := map[string]any{"title": "Test", "createdAt": "2025-05-13T00:00:00Z"}
+			maps.Copy(frontMatter, test.frontMatter)
+			page := testutil.ToContent(t, frontMatter, test.markdown)
+			p, err := markdown.New(test.opts).Parse(strings.NewReader(page))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if p.HTML != test.wantHTML {
+				t.Fatalf("expected HTML %q, got %q", test.wantHTML, p.HTML)
+			}
+			if p.Summary != test.wantSummary {
+				t.Fatalf("expected summary %q, got %q", test.wantSummary, p.Summary)
+			}
+		})
+	}
+}
+
+func TestParse_Text(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name            string
+		markdown        string
+		wantPlain       string
+		wantWordCount   int
+		wantReadingTime int
+	}{
+		{
+			name: "leaves out markup",
+			markdown: "# Title\n\nSome *emphasis*, `code` and [a link](/a).\n\n" +
+				"- one\n- two\n\n<div>raw</div>\n\n```go\nreturn nil\n```\n",
+			wantPlain:       "Title\nSome emphasis, code and a link.\none\ntwo\nreturn nil",
+			wantWordCount:   11,
+			wantReadingTime: 1,
+		},
+		{
+			name:            "rounds the reading time up",
+			markdown:        strings.Repeat("word ", markdown.WordsPerMinute+1),
+			wantPlain:       strings.TrimSpace(strings.Repeat("word ", markdown.WordsPerMinute+1)),
+			wantWordCount:   markdown.WordsPerMinute + 1,
+			wantReadingTime: 2,
+		},
+		{
+			name: "takes no time to read nothing",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			page := testutil.ToContent(
+				t,
+				map[string]any{"title": "Test", "createdAt": "2025-05-13T00:00:00Z"},
+				test.markdown,
+			)
+			p, err := markdown.New(markdown.Options{}).Parse(strings.NewReader(page))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if p.Plain != test.wantPlain {
+				t.Fatalf("expected plain text %q, got %q", test.wantPlain, p.Plain)
+			}
+			if p.WordCount != test.wantWordCount || p.ReadingTime != test.wantReadingTime {
+				t.Fatalf(
+					"expected %d words and %d minutes, got %d and %d",
+					test.wantWordCount,
+					test.wantReadingTime,
+					p.WordCount,
+					p.ReadingTime,
+				)
+			}
+		})
+	}
+}
+
+func TestParse_Links(t *testing.T) {
+	t.Parallel()
+
+	page := testutil.ToContent(
+		t,
+		map[string]any{"title": "Test", "createdAt": "2025-05-13T00:00:00Z"},
+		"See [setup](../setup.md#install) and\n[*my page*](my%20page.md), not "+
+			"[root](/a.md),\n[other](https://example.com/a.md), [query](a.md?x) or [html](a.html)."+
+			"\n\n<a href=\"../setup.md#install\">raw</a> `<a href=\"my%20page.md\">`",
+	)
+	md := markdown.New(markdown.Options{Unsafe: true})
+	p, err := md.Parse(strings.NewReader(page))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantLinks := []markdown.Link{
+		{Dest: "../setup.md#install", Path: "../setup.md", Fragment: "install", Line: 4, Column: 5},
+		{Dest: "my%20page.md", Path: "my page.md", Line: 5, Column: 2},
+	}
+	if !reflect.DeepEqual(p.Links, wantLinks) {
+		t.Fatalf("expected links %+v, got %+v", wantLinks, p.Links)
+	}
+
+	p, err = md.ParseWithURLs(strings.NewReader(page), map[string]string{
+		"../setup.md#install": "/setup/#install",
+		"my%20page.md":        "/my%20page/",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<p>See <a href="/setup/#install">setup</a> and` + "\n" +
+		`<a href="/my%20page/"><em>my page</em></a>, not <a href="/a.md">root</a>,` + "\n" +
+		`<a href="https://example.com/a.md" target="_blank" rel="noopener noreferrer">other</a>, ` +
+		`<a href="a.md?x">query</a> or <a href="a.html">html</a>.</p>` + "\n" +
+		`<p><a href="../setup.md#install">raw</a> ` +
+		`<code>&lt;a href=&quot;my%20page.md&quot;&gt;</code></p>` + "\n"
+	if p.HTML != template.HTML(want) {
+		t.Fatalf("expected %q, got %q", want, p.HTML)
+	}
+	if !reflect.DeepEqual(p.Links, wantLinks) {
+		t.Fatalf("expected links %+v, got %+v", wantLinks, p.Links)
+	}
+}
+
+func TestToHTML(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		opts     markdown.Options
+		markdown string
+		wantHTML template.HTML
+	}{
+		{
+			name:     "leaves out raw HTML by default",
+			markdown: "<b>bold</b> text\n[link](javascript:alert(1))",
+			wantHTML: "<p><!-- raw HTML omitted -->bold<!-- raw HTML omitted --> text\n" +
+				"<a href=\"\">link</a></p>\n",
+		},
+		{
+			name:     "renders raw HTML when unsafe",
+			opts:     markdown.Options{Unsafe: true},
+			markdown: "<b>bold</b> text\n[link](javascript:alert(1))",
+			wantHTML: "<p><b>bold</b> text\n<a href=\"javascript:alert(1)\">link</a></p>\n",
+		},
+		{
+			name:     "renders hard wraps",
+			opts:     markdown.Options{HardWraps: true},
+			markdown: "one\ntwo",
+			wantHTML: "<p>one<br>\ntwo</p>\n",
+		},
+		{
+			name:     "leaves extension syntax alone by default",
+			markdown: "~~gone~~ \"quoted\" https://example.com",
+			wantHTML: "<p>~~gone~~ &quot;quoted&quot; https://example.com</p>\n",
+		},
+		{
+			name:     "renders tables",
+			opts:     markdown.Options{Extensions: markdown.Extensions{Tables: true}},
+			markdown: "| a | b |\n|---|:-:|\n| 1 | 2 |",
+			wantHTML: "<table>\n<thead>\n<tr>\n<th>a</th>\n" +
+				"<th style=\"text-align:center\">b</th>\n</tr>\n</thead>\n" +
+				"<tbody>\n<tr>\n<td>1</td>\n<td style=\"text-align:center\">2</td>\n" +
+				"</tr>\n</tbody>\n</table>\n",
+		},
+		{
+			name:     "renders strikethrough",
+			opts:     markdown.Options{Extensions: markdown.Extensions{Strikethrough: true}},
+			markdown: "~~gone~~",
+			wantHTML: "<p><del>gone</del></p>\n",
+		},
+		{
+			name:     "renders task lists",
+			opts:     markdown.Options{Extensions: markdown.Extensions{TaskLists: true}},
+			markdown: "- [x] done\n- [ ] todo",
+			wantHTML: "<ul>\n<li><input checked=\"\" disabled=\"\" type=\"checkbox\"> done</li>\n" +
+				"<li><input disabled=\"\" type=\"checkbox\"> todo</li>\n</ul>\n",
+		},
+		{
+			name:     "renders autolinks as external links",
+			opts:     markdown.Options{Extensions: markdown.Extensions{Autolinks: true}},
+			markdown: "see www.example.com",
+			wantHTML: "<p>see <a href=\"http://www.example.com\" target=\"_blank\" " +
+				"rel=\"noopener noreferrer\">www.example.com</a></p>\n",
+		},
+		{
+			name:     "renders footnotes",
+			opts:     markdown.Options{Extensions: markdown.Extensions{Footnotes: true}},
+			markdown: "Text[^1]\n\n[^1]: A note.",
+			wantHTML: "<p>Text<sup id=\"fnref:1\"><a href=\"#fn:1\" class=\"footnote-ref\" " +
+				"role=\"doc-noteref\">1</a></sup></p>\n" +
+				"<div class=\"footnotes\" role=\"doc-endnotes\">\n<hr>\n<ol>\n<li id=\"fn:1\">\n" +
+				"<p>A note.&#160;<a href=\"#fnref:1\" class=\"footnote-backref\" " +
+				"role=\"doc-backlink\">&#x21a9;&#xfe0e;</a></p>\n</li>\n</ol>\n</div>\n",
+		},
+		{
+			name:     "renders definition lists",
+			opts:     markdown.Options{Extensions: markdown.Extensions{DefinitionLists: true}},
+			markdown: "Term\n: Definition",
+			wantHTML: "<dl>\n<dt>Term</dt>\n<dd>Definition</dd>\n</dl>\n",
+		},
+		{
+			name:     "replaces punctuation with the typographer",
+			opts:     markdown.Options{Extensions: markdown.Extensions{Typographer: true}},
+			markdown: "\"Quoted\" -- dash...",
+			wantHTML: "<p>&ldquo;Quoted&rdquo; &ndash; dash&hellip;</p>\n",
+		},
+		{
+			name:     "leaves code alone without highlighting",
+			markdown: "```go {lineNumbers}\nreturn nil\n```",
+			wantHTML: "<pre><code class=\"language-go\">return nil\n</code></pre>\n",
+		},
+		{
+			name:     "highlights code",
+			opts:     markdown.Options{Highlight: markdown.Highlight{Enabled: true}},
+			markdown: "```go\nreturn nil // <done>\n```\n\n```\nplain\n```",
+			wantHTML: "<pre class=\"highlight\"><code class=\"language-go\">" +
+				"<span class=\"hl-keyword\">return</span> <span class=\"hl-literal\">nil</span> " +
+				"<span class=\"hl-comment\">// &lt;done&gt;</span>\n</code></pre>\n" +
+				"<pre class=\"highlight\"><code>plain\n</code></pre>\n",
+		},
+		{
+			name:     "leaves code with invalid options unhighlighted",
+			opts:     markdown.Options{Highlight: markdown.Highlight{Enabled: true}},
+			markdown: "```go {highlight=x}\nif a < b {}\n```",
+			wantHTML: "<pre><code class=\"language-go\">if a &lt; b {}\n</code></pre>\n",
+		},
+		{
+			name: "numbers and marks lines from the info string",
+			opts: markdown.Options{
+				Highlight: markdown.Highlight{Enabled: true, LineNumbers: true},
+			},
+			markdown: "```text {start=9 highlight=2}\na\nb\n```\n\n" +
+				"```{lineNumbers=false}\nc\n```",
+			wantHTML: "<pre class=\"highlight\"><code class=\"language-text\">" +
+				"<span class=\"hl-line\"><span class=\"hl-ln\">9</span>" +
+				"<span class=\"hl-code\">a\n</span></span>" +
+				"<span class=\"hl-line hl-mark\"><span class=\"hl-ln\">10</span>" +
+				"<span class=\"hl-code\">b\n</span></span></code></pre>\n" +
+				"<pre class=\"highlight\"><code>c\n</code></pre>\n",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			html, err := markdown.New(test.opts).ToHTML([]byte(test.markdown))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if html != test.wantHTML {
+				t.Fatalf("expected %q, got %q", test.wantHTML, html)
+			}
+		})
+	}
+}
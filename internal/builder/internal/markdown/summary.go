@@ -0,0 +1,73 @@
+package markdown
+
+import (
+	"bytes"
+	"html"
+	"html/template"
+	"strings"
+
+	"github.com/yuin/goldmark/ast"
+)
+
+// DefaultSummaryLength is the number of words in summaries cut from the
+// text of a page when Options.SummaryLength is zero.
+const DefaultSummaryLength = 70
+
+// moreMarker separates the summary of a page from the rest of its content
+// when it is on a line of its own.
+const moreMarker = "<!--more-->"
+
+// cutMore removes the first moreMarker at the top level of doc and returns
+// the node that followed it, or nil if it was the last one. ok reports
+// whether doc had a marker.
+func cutMore(doc ast.Node, source []byte) (next ast.Node, ok bool) {
+	for n := doc.FirstChild(); n != nil; n = n.NextSibling() {
+		block, isHTML := n.(*ast.HTMLBlock)
+		if !isHTML || block.HTMLBlockType != ast.HTMLBlockType2 {
+			continue
+		}
+		lines := block.Lines()
+		if lines.Len() != 1 {
+			continue
+		}
+		line := lines.At(0)
+		if string(bytes.TrimSpace(line.Value(source))) != moreMarker {
+			continue
+		}
+		next = n.NextSibling()
+		doc.RemoveChild(doc, n)
+		return next, true
+	}
+	return nil, false
+}
+
+// removeFrom removes n and every node after it from their parent.
+func removeFrom(n ast.Node) {
+	for n != nil {
+		next := n.NextSibling()
+		n.Parent().RemoveChild(n.Parent(), n)
+		n = next
+	}
+}
+
+// frontMatterSummary returns the summary or, failing that, the description
+// in params, escaped as HTML.
+func frontMatterSummary(params map[string]any) (template.HTML, bool) {
+	for _, key := range []string{"summary", "description"} {
+		if s, ok := params[key].(string); ok && strings.TrimSpace(s) != "" {
+			return template.HTML(html.EscapeString(s)), true //nolint:gosec // Escaped.
+		}
+	}
+	return "", false
+}
+
+// textSummary returns the first words of text, escaped as HTML, followed by
+// an ellipsis if text has more words than that.
+func textSummary(text string, words int) template.HTML {
+	fields := strings.Fields(text)
+	summary := strings.Join(fields[:min(words, len(fields))], " ")
+	if len(fields) > words {
+		summary += "…"
+	}
+	return template.HTML(html.EscapeString(summary)) //nolint:gosec // Escaped.
+}
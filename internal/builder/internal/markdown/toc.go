@@ -0,0 +1,185 @@
+package markdown
+
+import (
+	"html"
+	"html/template"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+)
+
+// TOC configures which headings are listed in the table of contents of a
+// page. Headings of every level get an ID either way.
+type TOC struct {
+	// MinLevel and MaxLevel are the levels of the largest and smallest
+	// headings listed, from 1 for <h1> to 6 for <h6>. When zero, they are 1
+	// and 6.
+	MinLevel int
+	MaxLevel int
+}
+
+// Heading is an entry of the table of contents of a page.
+type Heading struct {
+	Level int
+	// ID is the id attribute of the heading, unique within the page, so that
+	// it can be linked to as #ID.
+	ID string
+	// Title is the text of the heading, without markup.
+	Title string
+	// Children holds the headings below this one until the next heading of
+	// the same or a larger level.
+	Children []Heading
+}
+
+var tocKey = parser.NewContextKey()
+
+// headingTransformer gives every heading an ID derived from its text and
+// stores the headings within the levels of the table of contents in the
+// parser context under tocKey.
+type headingTransformer struct {
+	minLevel int
+	maxLevel int
+}
+
+func (t *headingTransformer) Transform(
+	node *ast.Document,
+	reader text.Reader,
+	pc parser.Context,
+) {
+	ids := map[string]bool{}
+	headings := []Heading{}
+	_ = ast.Walk(node, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		heading, ok := n.(*ast.Heading)
+		if !entering || !ok {
+			return ast.WalkContinue, nil
+		}
+		title := plainText(heading, reader.Source())
+		id := uniqueID(ids, Slugify(title))
+		heading.SetAttribute([]byte("id"), []byte(id))
+		if heading.Level >= t.minLevel && heading.Level <= t.maxLevel {
+			headings = append(headings, Heading{Level: heading.Level, ID: id, Title: title})
+		}
+		return ast.WalkSkipChildren, nil
+	})
+	pc.Set(tocKey, nest(headings))
+}
+
+// Slugify lowercases s and replaces every run of characters other than
+// letters and digits with a single dash.
+func Slugify(s string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			dash = false
+			b.WriteRune(r)
+		} else {
+			dash = true
+		}
+	}
+	return b.String()
+}
+
+// uniqueID returns id, or "heading" if id is empty, followed by the first
+// suffix -1, -2, ... that makes it an ID not yet in ids, and adds it to ids.
+func uniqueID(ids map[string]bool, id string) string {
+	if id == "" {
+		id = "heading"
+	}
+	unique := id
+	for i := 1; ids[unique]; i++ {
+		unique = id + "-" + strconv.Itoa(i)
+	}
+	ids[unique] = true
+	return unique
+}
+
+// nest turns a flat list of headings into a tree, making every heading a
+// child of the closest heading before it with a larger level. It returns nil
+// if there are no headings.
+func nest(headings []Heading) []Heading {
+	var nested []Heading
+	for i := 0; i < len(headings); {
+		heading := headings[i]
+		end := i + 1
+		for end < len(headings) && headings[end].Level > heading.Level {
+			end++
+		}
+		heading.Children = nest(headings[i+1 : end])
+		nested = append(nested, heading)
+		i = end
+	}
+	return nested
+}
+
+// tocHTML renders headings as nested lists of links within a
+// <nav class="toc"> element, or returns "" if there are no headings.
+func tocHTML(headings []Heading) template.HTML {
+	if len(headings) == 0 {
+		return ""
+	}
+	b := &strings.Builder{}
+	b.WriteString(`<nav class="toc">`)
+	writeTOCList(b, headings)
+	b.WriteString("</nav>\n")
+	return template.HTML(b.String()) //nolint:gosec // Titles and IDs are escaped.
+}
+
+func writeTOCList(b *strings.Builder, headings []Heading) {
+	b.WriteString("<ul>")
+	for _, heading := range headings {
+		b.WriteString(`<li><a href="#` + html.EscapeString(heading.ID) + `">`)
+		b.WriteString(html.EscapeString(heading.Title) + "</a>")
+		if len(heading.Children) > 0 {
+			writeTOCList(b, heading.Children)
+		}
+		b.WriteString("</li>")
+	}
+	b.WriteString("</ul>")
+}
+
+// plainText returns the text of n and its descendants without markup, such
+// as the text of a heading or of a whole document. Every block ends a line,
+// line breaks become spaces and raw HTML is left out.
+func plainText(n ast.Node, source []byte) string {
+	b := &strings.Builder{}
+	_ = ast.Walk(n, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			text := b.String()
+			if n.Type() == ast.TypeBlock && text != "" && !strings.HasSuffix(text, "\n") {
+				b.WriteByte('\n')
+			}
+			return ast.WalkContinue, nil
+		}
+		switch n := n.(type) {
+		case *ast.Text:
+			b.Write(n.Value(source))
+			if n.SoftLineBreak() || n.HardLineBreak() {
+				b.WriteByte(' ')
+			}
+		case *ast.String:
+			// Strings, such as the quotes of the typographer, may hold
+			// HTML entities.
+			b.WriteString(html.UnescapeString(string(n.Value)))
+		case *ast.AutoLink:
+			b.Write(n.Label(source))
+		case *ast.CodeBlock, *ast.FencedCodeBlock:
+			lines := n.Lines()
+			for i := range lines.Len() {
+				segment := lines.At(i)
+				b.Write(segment.Value(source))
+			}
+		case *ast.RawHTML, *ast.HTMLBlock:
+			return ast.WalkSkipChildren, nil
+		}
+		return ast.WalkContinue, nil
+	})
+	return strings.TrimSpace(b.String())
+}
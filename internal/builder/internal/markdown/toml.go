@@ -0,0 +1,534 @@
+package markdown
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// tomlParser decodes TOML 1.0 front matter. Offset date-times are decoded as
+// times, and so are local dates and date-times, in UTC. Local times, which
+// have no date, are left as strings.
+type tomlParser struct {
+	src string
+	pos int
+	// keys holds the position of every top-level key and table.
+	keys map[string]position
+	// defined holds the paths of the tables defined by a [table] header,
+	// which can only be defined once.
+	defined map[string]bool
+}
+
+func parseTOML(src []byte) (map[string]any, map[string]position, error) {
+	p := &tomlParser{
+		src:     string(src),
+		keys:    make(map[string]position),
+		defined: make(map[string]bool),
+	}
+	root := map[string]any{}
+	current := root
+	atRoot := true
+	for {
+		p.skipBlank()
+		if p.eof() {
+			return root, p.keys, nil
+		}
+
+		start := p.pos
+		if p.peek() == '[' {
+			table, err := p.parseTableHeader(root)
+			if err != nil {
+				return nil, nil, err
+			}
+			current = table
+			atRoot = false
+		} else {
+			keys, err := p.parseKey()
+			if err != nil {
+				return nil, nil, err
+			}
+			if _, ok := p.keys[keys[0]]; !ok && atRoot {
+				p.keys[keys[0]] = p.position(start)
+			}
+			p.skipSpace()
+			if !p.consume("=") {
+				return nil, nil, p.errorf("expected '=' after key")
+			}
+			p.skipSpace()
+			valuePos := p.pos
+			value, err := p.parseValue()
+			if err != nil {
+				return nil, nil, err
+			}
+			if err := setTOMLKey(current, keys, value); err != nil {
+				return nil, nil, p.errorAt(valuePos, "%w", err)
+			}
+		}
+
+		p.skipSpace()
+		p.skipComment()
+		if !p.eof() && !p.consume("\n") && !p.consume("\r\n") {
+			return nil, nil, p.errorf("expected a new line, found %q", p.rest(10))
+		}
+	}
+}
+
+// parseTableHeader parses a [table] or [[array.of.tables]] header and returns
+// the table that the keys after it belong to.
+func (p *tomlParser) parseTableHeader(root map[string]any) (map[string]any, error) {
+	start := p.pos
+	array := p.consume("[[")
+	if !array {
+		p.consume("[")
+	}
+	p.skipSpace()
+	keys, err := p.parseKey()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if (array && !p.consume("]]")) || (!array && !p.consume("]")) {
+		return nil, p.errorf("expected ']' after table name")
+	}
+	if _, ok := p.keys[keys[0]]; !ok {
+		p.keys[keys[0]] = p.position(start)
+	}
+
+	parent, err := tomlTable(root, keys[:len(keys)-1])
+	if err != nil {
+		return nil, p.errorAt(start, "%w", err)
+	}
+	name := keys[len(keys)-1]
+	existing, ok := parent[name]
+	path := strings.Join(keys, "\x00")
+	if array {
+		if !ok {
+			existing = []map[string]any{}
+		}
+		tables, ok := existing.([]map[string]any)
+		if !ok {
+			return nil, p.errorAt(start, "%s is already defined", strings.Join(keys, "."))
+		}
+		table := map[string]any{}
+		parent[name] = append(tables, table)
+		// Tables below the previous table in the array can be defined again
+		// below this one.
+		for defined := range p.defined {
+			if strings.HasPrefix(defined, path+"\x00") {
+				delete(p.defined, defined)
+			}
+		}
+		return table, nil
+	}
+
+	if !ok {
+		existing = map[string]any{}
+		parent[name] = existing
+	}
+	table, ok := existing.(map[string]any)
+	if !ok || p.defined[path] {
+		return nil, p.errorAt(start, "%s is already defined", strings.Join(keys, "."))
+	}
+	p.defined[path] = true
+	return table, nil
+}
+
+// tomlTable returns the table at keys below root, creating missing tables.
+// For arrays of tables, the last table in the array is used.
+func tomlTable(root map[string]any, keys []string) (map[string]any, error) {
+	table := root
+	for i, key := range keys {
+		switch v := table[key].(type) {
+		case nil:
+			next := map[string]any{}
+			table[key] = next
+			table = next
+		case map[string]any:
+			table = v
+		case []map[string]any:
+			table = v[len(v)-1]
+		default:
+			return nil, fmt.Errorf("%s is not a table", strings.Join(keys[:i+1], "."))
+		}
+	}
+	return table, nil
+}
+
+// setTOMLKey sets the value of a possibly dotted key within table.
+func setTOMLKey(table map[string]any, keys []string, value any) error {
+	parent, err := tomlTable(table, keys[:len(keys)-1])
+	if err != nil {
+		return err
+	}
+	name := keys[len(keys)-1]
+	if _, ok := parent[name]; ok {
+		return fmt.Errorf("duplicate key %q", strings.Join(keys, "."))
+	}
+	parent[name] = value
+	return nil
+}
+
+func (p *tomlParser) eof() bool {
+	return p.pos >= len(p.src)
+}
+
+func (p *tomlParser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+func (p *tomlParser) consume(s string) bool {
+	if strings.HasPrefix(p.src[p.pos:], s) {
+		p.pos += len(s)
+		return true
+	}
+	return false
+}
+
+func (p *tomlParser) rest(n int) string {
+	rest := p.src[p.pos:]
+	if i := strings.IndexByte(rest, '\n'); i >= 0 {
+		rest = rest[:i]
+	}
+	return rest[:min(n, len(rest))]
+}
+
+func (p *tomlParser) skipSpace() {
+	for !p.eof() && (p.peek() == ' ' || p.peek() == '\t') {
+		p.pos++
+	}
+}
+
+func (p *tomlParser) skipComment() {
+	if p.peek() == '#' {
+		for !p.eof() && p.peek() != '\n' {
+			p.pos++
+		}
+	}
+}
+
+// skipBlank skips whitespace, new lines and comments.
+func (p *tomlParser) skipBlank() {
+	for {
+		p.skipSpace()
+		p.skipComment()
+		if !p.consume("\n") && !p.consume("\r\n") {
+			return
+		}
+	}
+}
+
+func (p *tomlParser) position(pos int) position {
+	prefix := p.src[:pos]
+	return position{
+		line:   strings.Count(prefix, "\n") + 1,
+		column: pos - strings.LastIndexByte(prefix, '\n'),
+	}
+}
+
+func (p *tomlParser) errorAt(pos int, format string, args ...any) error {
+	position := p.position(pos)
+	return &PositionError{
+		Line:   position.line,
+		Column: position.column,
+		Err:    fmt.Errorf(format, args...),
+	}
+}
+
+func (p *tomlParser) errorf(format string, args ...any) error {
+	return p.errorAt(p.pos, format, args...)
+}
+
+// parseKey parses a bare, quoted or dotted key.
+func (p *tomlParser) parseKey() ([]string, error) {
+	keys := []string{}
+	for {
+		p.skipSpace()
+		var key string
+		switch p.peek() {
+		case '"':
+			s, err := p.parseBasicString()
+			if err != nil {
+				return nil, err
+			}
+			key = s
+		case '\'':
+			s, err := p.parseLiteralString()
+			if err != nil {
+				return nil, err
+			}
+			key = s
+		default:
+			start := p.pos
+			for !p.eof() && isBareKeyChar(p.peek()) {
+				p.pos++
+			}
+			if start == p.pos {
+				return nil, p.errorf("expected a key, found %q", p.rest(10))
+			}
+			key = p.src[start:p.pos]
+		}
+		keys = append(keys, key)
+		p.skipSpace()
+		if !p.consume(".") {
+			return keys, nil
+		}
+	}
+}
+
+func isBareKeyChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' ||
+		c == '_' || c == '-'
+}
+
+func (p *tomlParser) parseValue() (any, error) {
+	p.skipSpace()
+	switch {
+	case p.eof() || p.peek() == '\n':
+		return nil, p.errorf("expected a value")
+	case strings.HasPrefix(p.src[p.pos:], `"""`):
+		return p.parseMultilineString(`"""`)
+	case strings.HasPrefix(p.src[p.pos:], `'''`):
+		return p.parseMultilineString(`'''`)
+	case p.peek() == '"':
+		return p.parseBasicString()
+	case p.peek() == '\'':
+		return p.parseLiteralString()
+	case p.peek() == '[':
+		return p.parseArray()
+	case p.peek() == '{':
+		return p.parseInlineTable()
+	case p.consume("true"):
+		return true, nil
+	case p.consume("false"):
+		return false, nil
+	}
+
+	start := p.pos
+	for !p.eof() && !strings.ContainsRune(" \t\r\n,]}#", rune(p.peek())) {
+		p.pos++
+	}
+	token := p.src[start:p.pos]
+	// Dates and times may be separated by a space.
+	if tomlDate.MatchString(token) && p.peek() == ' ' {
+		end := p.pos + 1
+		for end < len(p.src) && !strings.ContainsRune(" \t\r\n,]}#", rune(p.src[end])) {
+			end++
+		}
+		if tomlTime.MatchString(p.src[p.pos+1 : end]) {
+			token += "T" + p.src[p.pos+1:end]
+			p.pos = end
+		}
+	}
+	value, err := parseTOMLScalar(token)
+	if err != nil {
+		return nil, p.errorAt(start, "%w", err)
+	}
+	return value, nil
+}
+
+const (
+	tomlDigits         = `[0-9](_?[0-9])*`
+	tomlDatePattern    = `[0-9]{4}-[0-9]{2}-[0-9]{2}`
+	tomlTimePattern    = `[0-9]{2}:[0-9]{2}:[0-9]{2}(\.[0-9]+)?`
+	tomlOffsetPattern  = `([Zz]|[-+][0-9]{2}:[0-9]{2})`
+	tomlExponentSuffix = `[eE][-+]?` + tomlDigits
+)
+
+var (
+	tomlInt   = regexp.MustCompile(`^[-+]?(0|[1-9](_?[0-9])*)$`)
+	tomlHex   = regexp.MustCompile(`^0x[0-9A-Fa-f](_?[0-9A-Fa-f])*$`)
+	tomlOct   = regexp.MustCompile(`^0o[0-7](_?[0-7])*$`)
+	tomlBin   = regexp.MustCompile(`^0b[01](_?[01])*$`)
+	tomlFloat = regexp.MustCompile(
+		`^[-+]?(0|[1-9](_?[0-9])*)(\.` + tomlDigits + `(` + tomlExponentSuffix + `)?|` +
+			tomlExponentSuffix + `)$`,
+	)
+	tomlDate = regexp.MustCompile(`^` + tomlDatePattern + `$`)
+	// tomlTime also matches the time of a date-time written with a space.
+	tomlTime     = regexp.MustCompile(`^` + tomlTimePattern + tomlOffsetPattern + `?$`)
+	tomlDateTime = regexp.MustCompile(
+		`^` + tomlDatePattern + `[Tt]` + tomlTimePattern + tomlOffsetPattern + `?$`,
+	)
+	tomlOffset = regexp.MustCompile(tomlOffsetPattern + `$`)
+)
+
+func parseTOMLScalar(token string) (any, error) {
+	digits := strings.ReplaceAll(token, "_", "")
+	switch {
+	case tomlInt.MatchString(token):
+		return strconv.ParseInt(digits, 10, 64)
+	case tomlHex.MatchString(token), tomlOct.MatchString(token), tomlBin.MatchString(token):
+		return strconv.ParseInt(digits, 0, 64)
+	case tomlFloat.MatchString(token):
+		return strconv.ParseFloat(digits, 64)
+	case strings.TrimLeft(token, "+-") == "inf" || strings.TrimLeft(token, "+-") == "nan":
+		return nil, errors.New("inf and nan are not supported")
+	case tomlDateTime.MatchString(token) && tomlOffset.MatchString(token):
+		return time.Parse(time.RFC3339Nano, strings.ToUpper(token))
+	case tomlDateTime.MatchString(token):
+		return time.Parse("2006-01-02T15:04:05", strings.ToUpper(token))
+	case tomlDate.MatchString(token):
+		return time.Parse(time.DateOnly, token)
+	case tomlTime.MatchString(token) && !tomlOffset.MatchString(token):
+		return token, nil
+	}
+	return nil, fmt.Errorf("invalid value %q", token)
+}
+
+func (p *tomlParser) parseBasicString() (string, error) {
+	start := p.pos
+	p.pos++
+	b := strings.Builder{}
+	for {
+		if p.eof() || p.peek() == '\n' {
+			return "", p.errorAt(start, "unterminated string")
+		}
+		c := p.src[p.pos]
+		p.pos++
+		switch c {
+		case '"':
+			return b.String(), nil
+		case '\\':
+			if err := p.parseEscape(&b); err != nil {
+				return "", err
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+}
+
+func (p *tomlParser) parseLiteralString() (string, error) {
+	start := p.pos
+	p.pos++
+	end := strings.IndexAny(p.src[p.pos:], "'\n")
+	if end < 0 || p.src[p.pos+end] != '\'' {
+		return "", p.errorAt(start, "unterminated string")
+	}
+	s := p.src[p.pos : p.pos+end]
+	p.pos += end + 1
+	return s, nil
+}
+
+// parseMultilineString parses a string delimited by """ or ”'. A new line
+// right after the opening delimiter is not part of the string.
+func (p *tomlParser) parseMultilineString(delim string) (string, error) {
+	start := p.pos
+	p.pos += len(delim)
+	if !p.consume("\n") {
+		p.consume("\r\n")
+	}
+	b := strings.Builder{}
+	for {
+		if p.eof() {
+			return "", p.errorAt(start, "unterminated string")
+		}
+		if p.consume(delim) {
+			// Up to two quotes can come right before the closing delimiter.
+			for range 2 {
+				if p.consume(delim[:1]) {
+					b.WriteByte(delim[0])
+				}
+			}
+			return b.String(), nil
+		}
+		c := p.src[p.pos]
+		p.pos++
+		if c != '\\' || delim == `'''` {
+			b.WriteByte(c)
+			continue
+		}
+		// A backslash at the end of a line trims all whitespace up to the
+		// next character.
+		rest := strings.TrimLeft(p.src[p.pos:], " \t")
+		if strings.HasPrefix(rest, "\n") || strings.HasPrefix(rest, "\r\n") {
+			p.pos = len(p.src) - len(strings.TrimLeft(rest, " \t\r\n"))
+			continue
+		}
+		if err := p.parseEscape(&b); err != nil {
+			return "", err
+		}
+	}
+}
+
+// parseEscape writes the character escaped by the text after a backslash.
+func (p *tomlParser) parseEscape(b *strings.Builder) error {
+	start := p.pos - 1
+	if p.eof() {
+		return p.errorAt(start, "unterminated escape sequence")
+	}
+	c := p.src[p.pos]
+	if strings.IndexByte("0av/x ", c) >= 0 {
+		// These are valid in YAML but not in TOML.
+		return p.errorAt(start, "invalid escape sequence \\%c", c)
+	}
+	n, err := writeYAMLEscape(b, p.src[p.pos:])
+	if err != nil {
+		return p.errorAt(start, "%w", err)
+	}
+	p.pos += n
+	return nil
+}
+
+func (p *tomlParser) parseArray() ([]any, error) {
+	p.pos++
+	list := []any{}
+	for {
+		p.skipBlank()
+		if p.consume("]") {
+			return list, nil
+		}
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, value)
+		p.skipBlank()
+		if p.consume("]") {
+			return list, nil
+		}
+		if !p.consume(",") {
+			return nil, p.errorf("expected ',' or ']' in array")
+		}
+	}
+}
+
+func (p *tomlParser) parseInlineTable() (map[string]any, error) {
+	p.pos++
+	table := map[string]any{}
+	p.skipSpace()
+	if p.consume("}") {
+		return table, nil
+	}
+	for {
+		keys, err := p.parseKey()
+		if err != nil {
+			return nil, err
+		}
+		if !p.consume("=") {
+			return nil, p.errorf("expected '=' after key")
+		}
+		p.skipSpace()
+		valuePos := p.pos
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		if err := setTOMLKey(table, keys, value); err != nil {
+			return nil, p.errorAt(valuePos, "%w", err)
+		}
+		p.skipSpace()
+		if p.consume("}") {
+			return table, nil
+		}
+		if !p.consume(",") {
+			return nil, p.errorf("expected ',' or '}' in inline table")
+		}
+	}
+}
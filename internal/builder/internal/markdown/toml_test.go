@@ -0,0 +1,179 @@
+package markdown_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fivethirty/satisficer/internal/builder/internal/markdown"
+	"github.com/fivethirty/satisficer/internal/testutil"
+)
+
+func TestParse_TOML(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name            string
+		frontMatter     string
+		wantFrontMatter markdown.FrontMatter
+		wantError       string
+	}{
+		{
+			name: "can load front matter",
+			frontMatter: `# A comment
+title = "My \"Cool\" Page" # Another comment
+createdAt = 2023-06-09T12:00:00Z
+updatedAt = 2023-06-10 14:00:00+02:00
+template = 'page.html.tmpl'
+uglyURL = true
+tags = [
+  "go",
+  "static sites", # A trailing comma is fine.
+]
+`,
+			wantFrontMatter: markdown.FrontMatter{
+				Title:     `My "Cool" Page`,
+				CreatedAt: time.Date(2023, 6, 9, 12, 0, 0, 0, time.UTC),
+				UpdatedAt: testutil.Ptr(
+					t,
+					time.Date(2023, 6, 10, 14, 0, 0, 0, time.FixedZone("", 2*60*60)),
+				),
+				Template: "page.html.tmpl",
+				UglyURL:  true,
+				Tags:     []string{"go", "static sites"},
+			},
+		},
+		{
+			name: "can load params of any type",
+			frontMatter: `title = "Page"
+createdAt = 2023-06-09T12:00:00Z
+count = 1_000
+hex = 0xff
+ratio = -1.5e3
+date = 2023-06-09
+"quoted key" = "\u00e9"
+site.name = "Example"
+description = """
+A long \
+  description
+with "quotes"."""
+path = '''C:\Users'''
+point = { x = 1, y = 2 }
+
+[author]
+name = "Jane Doe"
+
+[[images]]
+src = "a.png"
+
+[[images]]
+src = "b.png"
+size = [800, 600]
+`,
+			wantFrontMatter: markdown.FrontMatter{
+				Title:     "Page",
+				CreatedAt: time.Date(2023, 6, 9, 12, 0, 0, 0, time.UTC),
+				Params: map[string]any{
+					"count":       float64(1000),
+					"hex":         float64(255),
+					"ratio":       -1500.0,
+					"date":        "2023-06-09T00:00:00Z",
+					"quoted key":  "é",
+					"site":        map[string]any{"name": "Example"},
+					"description": "A long description\nwith \"quotes\".",
+					"path":        `C:\Users`,
+					"point":       map[string]any{"x": float64(1), "y": float64(2)},
+					"author":      map[string]any{"name": "Jane Doe"},
+					"images": []any{
+						map[string]any{"src": "a.png"},
+						map[string]any{"src": "b.png", "size": []any{float64(800), float64(600)}},
+					},
+				},
+			},
+		},
+		{
+			name: "can load local dates and date-times as UTC",
+			frontMatter: `title = "Page"
+createdAt = 2025-05-13
+updatedAt = 2025-05-13T10:00:00
+expiresAt = 2025-05-14 10:00:00.5
+alarm = 07:30:00
+`,
+			wantFrontMatter: markdown.FrontMatter{
+				Title:     "Page",
+				CreatedAt: time.Date(2025, 5, 13, 0, 0, 0, 0, time.UTC),
+				UpdatedAt: testutil.Ptr(t, time.Date(2025, 5, 13, 10, 0, 0, 0, time.UTC)),
+				ExpiresAt: testutil.Ptr(t, time.Date(2025, 5, 14, 10, 0, 0, 5e8, time.UTC)),
+				Params:    map[string]any{"alarm": "07:30:00"},
+			},
+		},
+		{
+			name:        "reports syntax errors",
+			frontMatter: "title = \"Page\"\ncreatedAt 2023-06-09T12:00:00Z\n",
+			wantError:   "3:11: expected '=' after key",
+		},
+		{
+			name:        "reports invalid values",
+			frontMatter: "title = \"Page\"\ncount = 01\n",
+			wantError:   `3:9: invalid value "01"`,
+		},
+		{
+			name:        "reports duplicate keys",
+			frontMatter: "title = \"Page\"\ntitle = \"Other\"\n",
+			wantError:   `3:9: duplicate key "title"`,
+		},
+		{
+			name:        "reports tables defined twice",
+			frontMatter: "[author]\nname = \"Jane\"\n[author]\n",
+			wantError:   "4:1: author is already defined",
+		},
+		{
+			name:        "reports unterminated strings",
+			frontMatter: "title = \"Page\n",
+			wantError:   "2:9: unterminated string",
+		},
+		{
+			name:        "reports text after values",
+			frontMatter: "title = \"Page\" oops\n",
+			wantError:   `2:16: expected a new line, found "oops"`,
+		},
+		{
+			name:        "reports values of the wrong type at their key",
+			frontMatter: "title = \"Page\"\ncreatedAt = 2023-06-09T12:00:00Z\nuglyURL = 1\n",
+			wantError:   "4:1: json: cannot unmarshal number",
+		},
+		{
+			name:        "reports unquoted strings at their key",
+			frontMatter: "title = 123\ncreatedAt = 2023-06-09T12:00:00Z\n",
+			wantError:   "2:1: json: cannot unmarshal number",
+		},
+		{
+			name:        "reports invalid times at their key",
+			frontMatter: "title = \"Page\"\ncreatedAt = \"2025-05-13\"\n",
+			wantError: `3:1: createdAt: invalid time "2025-05-13", expected RFC 3339 such as ` +
+				`2006-01-02T15:04:05Z or 2006-01-02T15:04:05+02:00, ` +
+				`or unquoted 2006-01-02 or 2006-01-02 15:04:05`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			src := "+++\n" + test.frontMatter + "+++\n"
+			p, err := markdown.New(markdown.Options{}).Parse(strings.NewReader(src))
+			if test.wantError != "" {
+				if err == nil || !strings.HasPrefix(err.Error(), test.wantError) {
+					t.Fatalf("expected error %q, got %v", test.wantError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(p.FrontMatter, test.wantFrontMatter) {
+				t.Fatalf("got front matter %#v, want %#v", p.FrontMatter, test.wantFrontMatter)
+			}
+		})
+	}
+}
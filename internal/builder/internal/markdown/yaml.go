@@ -0,0 +1,666 @@
+package markdown
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// yamlParser decodes the subset of YAML that is useful in front matter:
+// block mappings and sequences, flow sequences and mappings, plain, quoted and
+// block scalars, and comments. Anchors, aliases, tags and documents other than
+// the first are not supported.
+type yamlParser struct {
+	lines []yamlLine
+	pos   int
+	// keys holds the position of every top-level key.
+	keys map[string]position
+	// stringKeys are the top-level keys whose plain scalars are read as
+	// strings, the way they would be decoded into a string field, and raw is
+	// set while the value of one of them is parsed.
+	stringKeys []string
+	raw        bool
+}
+
+type yamlLine struct {
+	// num is the 1-based line number within the front matter.
+	num    int
+	indent int
+	// raw is the line as written, used by block scalars.
+	raw string
+	// text is the line without indentation, comments and trailing spaces.
+	text string
+}
+
+// position is a 1-based line and column within the front matter.
+type position struct {
+	line   int
+	column int
+}
+
+// parseYAML parses front matter. Plain scalars in the values of stringKeys,
+// which are matched regardless of case, are kept as written rather than
+// resolved to numbers, booleans or timestamps, so that title: 2024 is the
+// string "2024".
+func parseYAML(src []byte, stringKeys ...string) (map[string]any, map[string]position, error) {
+	p := &yamlParser{keys: make(map[string]position), stringKeys: stringKeys}
+	for i, raw := range strings.Split(string(src), "\n") {
+		trimmed := strings.TrimLeft(raw, " ")
+		indent := len(raw) - len(trimmed)
+		text := strings.TrimRight(stripYAMLComment(trimmed), " \t")
+		if strings.HasPrefix(text, "\t") {
+			return nil, nil, yamlError(i+1, indent+1, "tabs are not allowed in indentation")
+		}
+		p.lines = append(p.lines, yamlLine{num: i + 1, indent: indent, raw: raw, text: text})
+	}
+
+	p.skipBlank()
+	if p.eof() {
+		return map[string]any{}, p.keys, nil
+	}
+	line := p.line()
+	if line.text == "---" || line.text == "..." || strings.HasPrefix(line.text, "%") {
+		return nil, nil, yamlError(line.num, 1, "directives and document markers are not supported")
+	}
+	if isYAMLSequenceItem(line.text) {
+		return nil, nil, yamlError(line.num, line.indent+1, "front matter must be a mapping")
+	}
+	m, err := p.parseMapping(line.indent, true)
+	if err != nil {
+		return nil, nil, err
+	}
+	p.skipBlank()
+	if !p.eof() {
+		line := p.line()
+		return nil, nil, yamlError(line.num, line.indent+1, "unexpected indentation")
+	}
+	return m, p.keys, nil
+}
+
+func yamlError(line int, column int, format string, args ...any) error {
+	return &PositionError{Line: line, Column: column, Err: fmt.Errorf(format, args...)}
+}
+
+func (p *yamlParser) eof() bool {
+	return p.pos >= len(p.lines)
+}
+
+func (p *yamlParser) line() *yamlLine {
+	return &p.lines[p.pos]
+}
+
+func (p *yamlParser) skipBlank() {
+	for !p.eof() && p.line().text == "" {
+		p.pos++
+	}
+}
+
+func isYAMLSequenceItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+// parseBlock parses the mapping or sequence starting at the current line,
+// which is indented by indent.
+func (p *yamlParser) parseBlock(indent int) (any, error) {
+	if isYAMLSequenceItem(p.line().text) {
+		return p.parseSequence(indent)
+	}
+	return p.parseMapping(indent, false)
+}
+
+func (p *yamlParser) parseMapping(indent int, top bool) (map[string]any, error) {
+	m := map[string]any{}
+	for {
+		p.skipBlank()
+		if p.eof() || p.line().indent < indent {
+			return m, nil
+		}
+		line := p.line()
+		if line.indent > indent {
+			return nil, yamlError(line.num, line.indent+1, "unexpected indentation")
+		}
+		if isYAMLSequenceItem(line.text) {
+			return nil, yamlError(line.num, line.indent+1, "expected a key, found a list item")
+		}
+
+		key, rest, err := splitYAMLKey(line.text)
+		if err != nil {
+			return nil, yamlError(line.num, line.indent+1, "%w", err)
+		}
+		if _, ok := m[key]; ok {
+			return nil, yamlError(line.num, line.indent+1, "duplicate key %q", key)
+		}
+		if top {
+			p.keys[key] = position{line: line.num, column: line.indent + 1}
+		}
+		column := line.indent + len(line.text) - len(rest) + 1
+		p.pos++
+
+		if top {
+			p.raw = slices.ContainsFunc(p.stringKeys, func(name string) bool {
+				return strings.EqualFold(key, name)
+			})
+		}
+		value, err := p.parseValue(indent, line.num, column, rest, true)
+		if err != nil {
+			return nil, err
+		}
+		m[key] = value
+	}
+}
+
+func (p *yamlParser) parseSequence(indent int) ([]any, error) {
+	list := []any{}
+	for {
+		p.skipBlank()
+		if p.eof() || p.line().indent < indent {
+			return list, nil
+		}
+		line := p.line()
+		if line.indent > indent {
+			return nil, yamlError(line.num, line.indent+1, "unexpected indentation")
+		}
+		if !isYAMLSequenceItem(line.text) {
+			// The sequence was the value of a key at the same indentation,
+			// and the next key follows it.
+			return list, nil
+		}
+
+		rest := strings.TrimLeft(line.text[1:], " ")
+		column := line.indent + len(line.text) - len(rest) + 1
+		if _, _, err := splitYAMLKey(rest); err == nil || isYAMLSequenceItem(rest) {
+			// A mapping or sequence starting on the same line as the dash is
+			// indented as far as its first entry.
+			line.indent = column - 1
+			line.text = rest
+			value, err := p.parseBlock(line.indent)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, value)
+			continue
+		}
+
+		p.pos++
+		value, err := p.parseValue(indent, line.num, column, rest, false)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, value)
+	}
+}
+
+// parseValue parses the value of a key or list item whose line is indented
+// by indent, given the rest of that line. Only the values of keys can be
+// sequences at the same indentation.
+func (p *yamlParser) parseValue(
+	indent int,
+	num int,
+	column int,
+	rest string,
+	isKey bool,
+) (any, error) {
+	switch {
+	case rest == "":
+		p.skipBlank()
+		if p.eof() {
+			return nil, nil
+		}
+		next := p.line()
+		if next.indent > indent {
+			return p.parseBlock(next.indent)
+		}
+		if isKey && next.indent == indent && isYAMLSequenceItem(next.text) {
+			return p.parseSequence(indent)
+		}
+		return nil, nil
+	case rest[0] == '|' || rest[0] == '>':
+		return p.parseBlockScalar(indent, num, column, rest)
+	}
+
+	value, err := parseYAMLInline(rest, p.raw)
+	if err != nil {
+		return nil, yamlError(num, column, "%w", err)
+	}
+	// Plain scalars can continue on more indented lines, unless those lines
+	// look like keys.
+	continued := false
+	for isPlainYAML(rest) && !p.eof() && p.line().text != "" && p.line().indent > indent {
+		if _, _, err := splitYAMLKey(p.line().text); err == nil {
+			break
+		}
+		rest += " " + p.line().text
+		continued = true
+		p.pos++
+	}
+	if continued {
+		return rest, nil
+	}
+	return value, nil
+}
+
+func isPlainYAML(s string) bool {
+	return !strings.ContainsRune(`"'[{`, rune(s[0]))
+}
+
+// parseBlockScalar parses a literal (|) or folded (>) scalar, whose lines are
+// more indented than indent.
+func (p *yamlParser) parseBlockScalar(indent int, num int, column int, header string) (any, error) {
+	style, chomp := header[0], header[1:]
+	if chomp != "" && chomp != "-" && chomp != "+" {
+		return nil, yamlError(num, column, "unsupported block scalar header %q", header)
+	}
+
+	lines := []string{}
+	blockIndent := -1
+	for ; !p.eof(); p.pos++ {
+		line := p.line()
+		if strings.TrimSpace(line.raw) == "" {
+			lines = append(lines, "")
+			continue
+		}
+		if blockIndent == -1 {
+			blockIndent = line.indent
+		}
+		if line.indent <= indent || line.indent < blockIndent {
+			break
+		}
+		lines = append(lines, line.raw[blockIndent:])
+	}
+
+	trailing := 0
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+		trailing++
+	}
+	// Blank lines that follow the scalar are not part of it.
+	p.pos -= trailing
+	if len(lines) == 0 {
+		return "", nil
+	}
+
+	var s string
+	if style == '|' {
+		s = strings.Join(lines, "\n")
+	} else {
+		// Lines are joined by spaces, and each blank line becomes a new line.
+		b := strings.Builder{}
+		for i, line := range lines {
+			switch {
+			case line == "":
+				b.WriteString("\n")
+			case i > 0 && lines[i-1] != "":
+				b.WriteString(" ")
+			}
+			b.WriteString(line)
+		}
+		s = b.String()
+	}
+	switch chomp {
+	case "":
+		s += "\n"
+	case "+":
+		s += strings.Repeat("\n", trailing+1)
+		p.pos += trailing
+	}
+	return s, nil
+}
+
+// splitYAMLKey splits "key: value" into its key and the rest of the line.
+func splitYAMLKey(text string) (string, string, error) {
+	var key string
+	rest := text
+	if text != "" && (text[0] == '"' || text[0] == '\'') {
+		var err error
+		key, rest, err = readYAMLQuoted(text)
+		if err != nil {
+			return "", "", err
+		}
+		if !strings.HasPrefix(rest, ":") {
+			return "", "", errors.New("expected ':' after key")
+		}
+		rest = rest[1:]
+	} else {
+		i := strings.Index(text, ": ")
+		switch {
+		case i >= 0:
+		case strings.HasSuffix(text, ":"):
+			i = len(text) - 1
+		default:
+			return "", "", errors.New("expected a key followed by ':'")
+		}
+		key, rest = text[:i], text[i+1:]
+		if key == "" {
+			return "", "", errors.New("empty key")
+		}
+		if strings.ContainsAny(key[:1], "&*!?|>[]{},") {
+			return "", "", fmt.Errorf("unsupported key %q", key)
+		}
+	}
+	if rest != "" && rest[0] != ' ' {
+		return "", "", errors.New("expected a space after ':'")
+	}
+	return key, strings.TrimLeft(rest, " "), nil
+}
+
+// stripYAMLComment removes a comment from the end of line. A # only starts a
+// comment at the start of the line or after whitespace, outside of quotes.
+func stripYAMLComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0 && c == quote:
+			quote = 0
+		case quote != 0:
+		case c == '"' || c == '\'':
+			if i == 0 || strings.ContainsRune(" \t[{,:-", rune(line[i-1])) {
+				quote = c
+			}
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// parseYAMLInline parses a value that is written on a single line. With raw,
+// plain scalars other than null are kept as strings.
+func parseYAMLInline(s string, raw bool) (any, error) {
+	f := &yamlFlow{s: s, raw: raw}
+	value, err := f.value(false)
+	if err != nil {
+		return nil, err
+	}
+	f.skipSpace()
+	if f.i < len(f.s) {
+		return nil, fmt.Errorf("unexpected %q after value", f.s[f.i:])
+	}
+	return value, nil
+}
+
+// yamlFlow parses flow collections such as [a, b] and {a: 1}, and scalars.
+type yamlFlow struct {
+	s   string
+	i   int
+	raw bool
+}
+
+func (f *yamlFlow) skipSpace() {
+	for f.i < len(f.s) && f.s[f.i] == ' ' {
+		f.i++
+	}
+}
+
+// value parses the value at the current position. Inside a flow collection,
+// plain scalars end at the next flow indicator.
+func (f *yamlFlow) value(inFlow bool) (any, error) {
+	f.skipSpace()
+	if f.i == len(f.s) {
+		return nil, nil
+	}
+	switch f.s[f.i] {
+	case '[':
+		return f.sequence()
+	case '{':
+		return f.mapping()
+	case '"', '\'':
+		s, rest, err := readYAMLQuoted(f.s[f.i:])
+		if err != nil {
+			return nil, err
+		}
+		f.i = len(f.s) - len(rest)
+		return s, nil
+	case '&', '*', '!':
+		return nil, errors.New("anchors, aliases and tags are not supported")
+	case '|', '>':
+		return nil, errors.New("block scalars must start on their own line")
+	}
+
+	start := f.i
+	for f.i < len(f.s) && !(inFlow && strings.ContainsRune(",[]{}", rune(f.s[f.i]))) {
+		f.i++
+	}
+	plain := strings.TrimRight(f.s[start:f.i], " ")
+	if err := checkPlainYAML(plain); err != nil {
+		return nil, err
+	}
+	value := resolveYAMLScalar(plain)
+	if _, isString := value.(string); f.raw && value != nil && !isString {
+		return plain, nil
+	}
+	return value, nil
+}
+
+// checkPlainYAML rejects plain scalars that YAML reads as something else or
+// not at all, such as Hello: World, which is a mapping inside a mapping, or
+// values starting with a reserved indicator. Such values have to be quoted.
+func checkPlainYAML(s string) error {
+	reason := ""
+	switch {
+	case strings.Contains(s, ": ") || strings.HasSuffix(s, ":"):
+		reason = `contains ": " or ends with ':'`
+	case s == "-" || s == "?" || strings.HasPrefix(s, "- ") || strings.HasPrefix(s, "? "):
+		reason = fmt.Sprintf("starts with %q", s[:1])
+	case s != "" && strings.ContainsRune("@`%,", rune(s[0])):
+		reason = fmt.Sprintf("starts with %q", s[:1])
+	default:
+		return nil
+	}
+	return fmt.Errorf("value %q %s, quote it", s, reason)
+}
+
+func (f *yamlFlow) sequence() ([]any, error) {
+	f.i++
+	list := []any{}
+	for {
+		f.skipSpace()
+		if f.i == len(f.s) {
+			return nil, errors.New("unterminated flow sequence")
+		}
+		if f.s[f.i] == ']' {
+			f.i++
+			return list, nil
+		}
+		value, err := f.value(true)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, value)
+		if err := f.separator(']'); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (f *yamlFlow) mapping() (map[string]any, error) {
+	f.i++
+	m := map[string]any{}
+	for {
+		f.skipSpace()
+		if f.i == len(f.s) {
+			return nil, errors.New("unterminated flow mapping")
+		}
+		if f.s[f.i] == '}' {
+			f.i++
+			return m, nil
+		}
+
+		var key string
+		if c := f.s[f.i]; c == '"' || c == '\'' {
+			k, rest, err := readYAMLQuoted(f.s[f.i:])
+			if err != nil {
+				return nil, err
+			}
+			key = k
+			f.i = len(f.s) - len(rest)
+		} else {
+			start := f.i
+			for f.i < len(f.s) && !strings.ContainsRune(":,[]{}", rune(f.s[f.i])) {
+				f.i++
+			}
+			key = strings.TrimRight(f.s[start:f.i], " ")
+		}
+		f.skipSpace()
+		if f.i == len(f.s) || f.s[f.i] != ':' {
+			return nil, fmt.Errorf("expected ':' after key %q", key)
+		}
+		f.i++
+		if _, ok := m[key]; ok {
+			return nil, fmt.Errorf("duplicate key %q", key)
+		}
+		value, err := f.value(true)
+		if err != nil {
+			return nil, err
+		}
+		m[key] = value
+		if err := f.separator('}'); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// separator consumes the comma after an entry of a flow collection, leaving
+// the closing bracket for the caller.
+func (f *yamlFlow) separator(end byte) error {
+	f.skipSpace()
+	switch {
+	case f.i == len(f.s):
+		return nil
+	case f.s[f.i] == ',':
+		f.i++
+		return nil
+	case f.s[f.i] == end:
+		return nil
+	}
+	return fmt.Errorf("expected ',' or '%c', found %q", end, f.s[f.i:])
+}
+
+// readYAMLQuoted reads the quoted scalar at the start of s and returns it along
+// with the rest of s.
+func readYAMLQuoted(s string) (string, string, error) {
+	quote := s[0]
+	b := strings.Builder{}
+	for i := 1; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == quote && quote == '\'' && i+1 < len(s) && s[i+1] == '\'':
+			b.WriteByte('\'')
+			i++
+		case c == quote:
+			return b.String(), s[i+1:], nil
+		case c == '\\' && quote == '"':
+			n, err := writeYAMLEscape(&b, s[i+1:])
+			if err != nil {
+				return "", "", err
+			}
+			i += n
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", "", errors.New("unterminated quoted string")
+}
+
+// writeYAMLEscape writes the character escaped by the start of s, which
+// follows a backslash, and returns how many bytes of s it used.
+func writeYAMLEscape(b *strings.Builder, s string) (int, error) {
+	if s == "" {
+		return 0, errors.New("unterminated escape sequence")
+	}
+	simple := map[byte]string{
+		'0': "\x00", 'a': "\a", 'b': "\b", 't': "\t", 'n': "\n", 'v': "\v", 'f': "\f",
+		'r': "\r", 'e': "\x1b", ' ': " ", '"': "\"", '/': "/", '\\': "\\",
+	}
+	if r, ok := simple[s[0]]; ok {
+		b.WriteString(r)
+		return 1, nil
+	}
+	digits := map[byte]int{'x': 2, 'u': 4, 'U': 8}[s[0]]
+	if digits == 0 || len(s) < 1+digits {
+		return 0, fmt.Errorf("invalid escape sequence \\%c", s[0])
+	}
+	code, err := strconv.ParseUint(s[1:1+digits], 16, 32)
+	if err != nil || !utf8.ValidRune(rune(code)) {
+		return 0, fmt.Errorf("invalid escape sequence \\%s", s[:1+digits])
+	}
+	b.WriteRune(rune(code))
+	return 1 + digits, nil
+}
+
+var (
+	yamlInt   = regexp.MustCompile(`^[-+]?[0-9]+$`)
+	yamlFloat = regexp.MustCompile(`^[-+]?(\.[0-9]+|[0-9]+(\.[0-9]*)?)([eE][-+]?[0-9]+)?$`)
+	// yamlTimestamp matches the timestamps of YAML 1.1, capturing the date,
+	// the time and the time zone.
+	yamlTimestamp = regexp.MustCompile(
+		`^([0-9]{4}-[0-9]{1,2}-[0-9]{1,2})` +
+			`(?:(?:[Tt]|[ \t]+)([0-9]{1,2}:[0-9]{2}:[0-9]{2}(?:\.[0-9]*)?)` +
+			`(?:[ \t]*(Z|[-+][0-9]{1,2}(?::[0-9]{2})?))?)?$`,
+	)
+)
+
+// resolveYAMLScalar returns the value of a plain scalar according to the
+// YAML 1.2 core schema, extended with the timestamps of YAML 1.1 that front
+// matter is commonly written with.
+func resolveYAMLScalar(s string) any {
+	switch s {
+	case "", "~", "null", "Null", "NULL":
+		return nil
+	case "true", "True", "TRUE":
+		return true
+	case "false", "False", "FALSE":
+		return false
+	}
+	if yamlInt.MatchString(s) {
+		if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+			return n
+		}
+	}
+	if yamlFloat.MatchString(s) {
+		if n, err := strconv.ParseFloat(s, 64); err == nil {
+			return n
+		}
+	}
+	if t, ok := parseYAMLTimestamp(s); ok {
+		return t
+	}
+	return s
+}
+
+// parseYAMLTimestamp parses a timestamp such as 2023-06-09,
+// 2023-06-09 12:00:00 or 2023-06-09T12:00:00+02:00. Timestamps without a
+// time are at midnight and those without a time zone are in UTC.
+func parseYAMLTimestamp(s string) (time.Time, bool) {
+	m := yamlTimestamp.FindStringSubmatch(s)
+	if m == nil {
+		return time.Time{}, false
+	}
+	clock := m[2]
+	if clock == "" {
+		clock = "00:00:00"
+	}
+	t, err := time.Parse("2006-1-2 15:04:05", m[1]+" "+clock)
+	if err != nil {
+		return time.Time{}, false
+	}
+	if m[3] == "" || m[3] == "Z" {
+		return t, true
+	}
+	hours, minutes, _ := strings.Cut(m[3][1:], ":")
+	h, _ := strconv.Atoi(hours)
+	mins, _ := strconv.Atoi(minutes)
+	offset := h*60*60 + mins*60
+	if m[3][0] == '-' {
+		offset = -offset
+	}
+	zone := time.FixedZone("", offset)
+	return t.Add(-time.Duration(offset) * time.Second).In(zone), true
+}
@@ -0,0 +1,276 @@
+package markdown_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fivethirty/satisficer/internal/builder/internal/markdown"
+	"github.com/fivethirty/satisficer/internal/testutil"
+)
+
+func TestParse_YAML(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name            string
+		frontMatter     string
+		wantFrontMatter markdown.FrontMatter
+		wantError       string
+	}{
+		{
+			name: "can load front matter",
+			frontMatter: `# A comment
+title: My "Cool" Page # Another comment
+createdAt: 2023-06-09T12:00:00Z
+updatedAt: '2023-06-10T12:00:00Z'
+template: "page.html.tmpl"
+uglyURL: true
+draft: false
+expiresAt: ~
+`,
+			wantFrontMatter: markdown.FrontMatter{
+				Title:     `My "Cool" Page`,
+				CreatedAt: time.Date(2023, 6, 9, 12, 0, 0, 0, time.UTC),
+				UpdatedAt: testutil.Ptr(t, time.Date(2023, 6, 10, 12, 0, 0, 0, time.UTC)),
+				Template:  "page.html.tmpl",
+				UglyURL:   true,
+			},
+		},
+		{
+			name: "can load sequences",
+			frontMatter: `title: Page
+createdAt: 2023-06-09T12:00:00Z
+tags: [go, "static sites", 'it''s']
+categories:
+- notes
+-   "more notes"
+`,
+			wantFrontMatter: markdown.FrontMatter{
+				Title:      "Page",
+				CreatedAt:  time.Date(2023, 6, 9, 12, 0, 0, 0, time.UTC),
+				Tags:       []string{"go", "static sites", "it's"},
+				Categories: []string{"notes", "more notes"},
+			},
+		},
+		{
+			name: "can load params of any type",
+			frontMatter: `title: Page
+createdAt: 2023-06-09T12:00:00Z
+count: 3
+ratio: -1.5e3
+enabled: yes
+empty:
+url: https://example.com/#top
+escaped: "tab\tand é"
+author:
+  name: Jane Doe
+  links:
+    - https://example.com
+    - {name: Mastodon, url: "https://example.social"}
+images:
+  - src: a.png
+    alt: An image
+    size: [800, 600]
+  - src: b.png
+long: a plain scalar
+  that continues
+`,
+			wantFrontMatter: markdown.FrontMatter{
+				Title:     "Page",
+				CreatedAt: time.Date(2023, 6, 9, 12, 0, 0, 0, time.UTC),
+				Params: map[string]any{
+					"count":   float64(3),
+					"ratio":   -1500.0,
+					"enabled": "yes",
+					"empty":   nil,
+					"url":     "https://example.com/#top",
+					"escaped": "tab\tand é",
+					"author": map[string]any{
+						"name": "Jane Doe",
+						"links": []any{
+							"https://example.com",
+							map[string]any{"name": "Mastodon", "url": "https://example.social"},
+						},
+					},
+					"images": []any{
+						map[string]any{
+							"src":  "a.png",
+							"alt":  "An image",
+							"size": []any{float64(800), float64(600)},
+						},
+						map[string]any{"src": "b.png"},
+					},
+					"long": "a plain scalar that continues",
+				},
+			},
+		},
+		{
+			name: "can load block scalars",
+			frontMatter: `title: Page
+createdAt: 2023-06-09T12:00:00Z
+literal: |
+  line one
+    indented
+
+  line three
+folded: >-
+  folded
+  text
+
+  paragraph
+kept: |+
+  kept
+
+template: page.html.tmpl
+`,
+			wantFrontMatter: markdown.FrontMatter{
+				Title:     "Page",
+				CreatedAt: time.Date(2023, 6, 9, 12, 0, 0, 0, time.UTC),
+				Template:  "page.html.tmpl",
+				Params: map[string]any{
+					"literal": "line one\n  indented\n\nline three\n",
+					"folded":  "folded text\nparagraph",
+					"kept":    "kept\n\n",
+				},
+			},
+		},
+		{
+			name: "can load timestamps",
+			frontMatter: `title: Page
+createdAt: 2025-05-13
+updatedAt: 2025-05-13 10:00:00
+expiresAt: 2025-5-14t10:00:00.5 +02:00
+published: [2025-05-13T10:00:00Z, '2025-05-13']
+`,
+			wantFrontMatter: markdown.FrontMatter{
+				Title:     "Page",
+				CreatedAt: time.Date(2025, 5, 13, 0, 0, 0, 0, time.UTC),
+				UpdatedAt: testutil.Ptr(t, time.Date(2025, 5, 13, 10, 0, 0, 0, time.UTC)),
+				ExpiresAt: testutil.Ptr(
+					t,
+					time.Date(2025, 5, 14, 10, 0, 0, 5e8, time.FixedZone("", 2*60*60)),
+				),
+				Params: map[string]any{
+					"published": []any{"2025-05-13T10:00:00Z", "2025-05-13"},
+				},
+			},
+		},
+		{
+			name: "reads plain scalars of string fields as written",
+			frontMatter: `title: 123
+createdAt: 2023-06-09T12:00:00Z
+template: 1.50
+tags: [true, 2024, ~]
+categories:
+- 2023-06-09
+- null
+count: 123
+`,
+			wantFrontMatter: markdown.FrontMatter{
+				Title:      "123",
+				CreatedAt:  time.Date(2023, 6, 9, 12, 0, 0, 0, time.UTC),
+				Template:   "1.50",
+				Tags:       []string{"true", "2024", ""},
+				Categories: []string{"2023-06-09", ""},
+				Params:     map[string]any{"count": float64(123)},
+			},
+		},
+		{
+			name: "can load quoted scalars containing a key",
+			frontMatter: "title: \"Hello: World\"\ncreatedAt: 2023-06-09T12:00:00Z\n" +
+				"tags: ['a: b']\n",
+			wantFrontMatter: markdown.FrontMatter{
+				Title:     "Hello: World",
+				CreatedAt: time.Date(2023, 6, 9, 12, 0, 0, 0, time.UTC),
+				Tags:      []string{"a: b"},
+			},
+		},
+		{
+			name:        "reports syntax errors",
+			frontMatter: "title: Page\ncreatedAt: 2023-06-09T12:00:00Z\n  oops: true\n",
+			wantError:   "4:3: unexpected indentation",
+		},
+		{
+			name:        "reports lines without keys",
+			frontMatter: "title: Page\njust some text\n",
+			wantError:   "3:1: expected a key followed by ':'",
+		},
+		{
+			name:        "reports duplicate keys",
+			frontMatter: "title: Page\ntitle: Other\n",
+			wantError:   `3:1: duplicate key "title"`,
+		},
+		{
+			name:        "reports unterminated strings",
+			frontMatter: "title: \"Page\n",
+			wantError:   "2:8: unterminated quoted string",
+		},
+		{
+			name:        "reports unsupported features",
+			frontMatter: "title: &anchor Page\n",
+			wantError:   "2:8: anchors, aliases and tags are not supported",
+		},
+		{
+			name:        "reports plain scalars containing a key",
+			frontMatter: "title: Hello: World\n",
+			wantError:   `2:8: value "Hello: World" contains ": " or ends with ':', quote it`,
+		},
+		{
+			name:        "reports plain scalars ending with a colon",
+			frontMatter: "title: Page\nnote: see:\n",
+			wantError:   `3:7: value "see:" contains ": " or ends with ':', quote it`,
+		},
+		{
+			name:        "reports plain scalars containing a key in flow sequences",
+			frontMatter: "tags: [a: b]\n",
+			wantError:   `2:7: value "a: b" contains ": " or ends with ':', quote it`,
+		},
+		{
+			name:        "reports plain scalars starting with a list item",
+			frontMatter: "tags: - go\n",
+			wantError:   `2:7: value "- go" starts with "-", quote it`,
+		},
+		{
+			name:        "reports plain scalars starting with a reserved indicator",
+			frontMatter: "title: @home\n",
+			wantError:   `2:8: value "@home" starts with "@", quote it`,
+		},
+		{
+			name:        "reports tabs in indentation",
+			frontMatter: "author:\n\tname: Jane\n",
+			wantError:   "3:1: tabs are not allowed in indentation",
+		},
+		{
+			name:        "reports values of the wrong type at their key",
+			frontMatter: "title: Page\ncreatedAt: 2023-06-09T12:00:00Z\nUglyURL: [1]\n",
+			wantError:   "4:1: json: cannot unmarshal array",
+		},
+		{
+			name:        "reports invalid times at their key",
+			frontMatter: "title: Page\ncreatedAt: 2025-13-01\n",
+			wantError:   `3:1: createdAt: invalid time "2025-13-01", expected RFC 3339`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			src := "---\n" + test.frontMatter + "---\n"
+			p, err := markdown.New(markdown.Options{}).Parse(strings.NewReader(src))
+			if test.wantError != "" {
+				if err == nil || !strings.HasPrefix(err.Error(), test.wantError) {
+					t.Fatalf("expected error %q, got %v", test.wantError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(p.FrontMatter, test.wantFrontMatter) {
+				t.Fatalf("got front matter %#v, want %#v", p.FrontMatter, test.wantFrontMatter)
+			}
+		})
+	}
+}
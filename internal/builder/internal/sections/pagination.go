@@ -0,0 +1,48 @@
+package sections
+
+import (
+	"path"
+	"strconv"
+)
+
+// Paginator is one page of a paginated listing.
+type Paginator struct {
+	// Pages holds the pages listed on this page.
+	Pages Pages
+	// Number is the position of this page, starting at 1.
+	Number  int
+	URL     string
+	PrevURL string
+	NextURL string
+	// Pagers holds every page of the listing, including this one.
+	Pagers []*Paginator
+}
+
+// Paginate splits p into pages of size entries each. The first page is at
+// url and page N at page/N/index.html in the directory of url. There is
+// always at least one page, even if p is empty.
+func (p Pages) Paginate(size int, url string) []*Paginator {
+	count := max(1, (len(p)+size-1)/size)
+	pagers := make([]*Paginator, 0, count)
+	for i := range count {
+		pager := &Paginator{
+			Pages:  p[i*size : min((i+1)*size, len(p))],
+			Number: i + 1,
+			URL:    url,
+		}
+		if i > 0 {
+			pager.URL = path.Join(path.Dir(url), "page", strconv.Itoa(i+1), "index.html")
+		}
+		pagers = append(pagers, pager)
+	}
+	for i, pager := range pagers {
+		pager.Pagers = pagers
+		if i > 0 {
+			pager.PrevURL = pagers[i-1].URL
+		}
+		if i < len(pagers)-1 {
+			pager.NextURL = pagers[i+1].URL
+		}
+	}
+	return pagers
+}
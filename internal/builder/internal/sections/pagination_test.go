@@ -0,0 +1,102 @@
+package sections_test
+
+import (
+	"testing"
+
+	"github.com/fivethirty/satisficer/internal/builder/internal/sections"
+)
+
+func TestPaginate(t *testing.T) {
+	t.Parallel()
+
+	pages := func(n int) sections.Pages {
+		p := sections.Pages{}
+		for i := range n {
+			p = append(p, sections.Page{Title: string(rune('A' + i))})
+		}
+		return p
+	}
+
+	type wantPager struct {
+		titles  string
+		url     string
+		prevURL string
+		nextURL string
+	}
+
+	tests := []struct {
+		name  string
+		pages sections.Pages
+		size  int
+		url   string
+		want  []wantPager
+	}{
+		{
+			name:  "splits pages",
+			pages: pages(5),
+			size:  2,
+			url:   "blog/index.html",
+			want: []wantPager{
+				{titles: "AB", url: "blog/index.html", nextURL: "blog/page/2/index.html"},
+				{
+					titles:  "CD",
+					url:     "blog/page/2/index.html",
+					prevURL: "blog/index.html",
+					nextURL: "blog/page/3/index.html",
+				},
+				{titles: "E", url: "blog/page/3/index.html", prevURL: "blog/page/2/index.html"},
+			},
+		},
+		{
+			name:  "fills the last page exactly",
+			pages: pages(4),
+			size:  2,
+			url:   "index.html",
+			want: []wantPager{
+				{titles: "AB", url: "index.html", nextURL: "page/2/index.html"},
+				{titles: "CD", url: "page/2/index.html", prevURL: "index.html"},
+			},
+		},
+		{
+			name:  "has one page when there are no pages",
+			pages: pages(0),
+			size:  2,
+			url:   "blog/index.html",
+			want: []wantPager{
+				{url: "blog/index.html"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			pagers := test.pages.Paginate(test.size, test.url)
+			if len(pagers) != len(test.want) {
+				t.Fatalf("expected %d pagers, got %d", len(test.want), len(pagers))
+			}
+			for i, pager := range pagers {
+				titles := ""
+				for _, page := range pager.Pages {
+					titles += page.Title
+				}
+				got := wantPager{
+					titles:  titles,
+					url:     pager.URL,
+					prevURL: pager.PrevURL,
+					nextURL: pager.NextURL,
+				}
+				if got != test.want[i] {
+					t.Fatalf("expected pager %d to be %+v, got %+v", i+1, test.want[i], got)
+				}
+				if pager.Number != i+1 {
+					t.Fatalf("expected pager %d to have number %d, got %d", i+1, i+1, pager.Number)
+				}
+				if len(pager.Pagers) != len(pagers) || pager.Pagers[i] != pager {
+					t.Fatalf("expected pager %d to list every pager", i+1)
+				}
+			}
+		})
+	}
+}
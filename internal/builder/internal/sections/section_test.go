@@ -1,14 +1,17 @@
 package sections_test
 
 import (
+	"errors"
 	"io"
 	"io/fs"
 	"reflect"
 	"sort"
+	"strings"
 	"testing"
 	"testing/fstest"
 	"time"
 
+	"github.com/fivethirty/satisficer/internal/builder/internal/config"
 	"github.com/fivethirty/satisficer/internal/builder/internal/markdown"
 	"github.com/fivethirty/satisficer/internal/builder/internal/sections"
 )
@@ -19,12 +22,27 @@ func fakeParseFunc(r io.Reader) (*markdown.ParsedFile, error) {
 		return nil, err
 	}
 
-	// Simple parser that looks for "uglyURL" in the content
+	if string(content) == "error" {
+		return nil, errors.New("bad content")
+	}
+
+	if string(content) == "badTag" {
+		return &markdown.ParsedFile{
+			FrontMatter: markdown.FrontMatter{Tags: []string{"go", "!!!"}},
+		}, nil
+	}
+
+	// Simple parser that looks for "uglyURL" or a template in the content
 	uglyURL := string(content) == "uglyURL"
+	template := ""
+	if strings.HasSuffix(string(content), ".tmpl") {
+		template = string(content)
+	}
 
 	return &markdown.ParsedFile{
 		FrontMatter: markdown.FrontMatter{
-			UglyURL: uglyURL,
+			UglyURL:  uglyURL,
+			Template: template,
 		},
 	}, nil
 }
@@ -119,12 +137,70 @@ func TestFromFS(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "uses the extension of non-HTML templates",
+			contentFS: fstest.MapFS{
+				"feed.md":       &fstest.MapFile{Data: []byte("feed.xml.tmpl")},
+				"post.md":       &fstest.MapFile{Data: []byte("page.html.tmpl")},
+				"blog/index.md": &fstest.MapFile{Data: []byte("robots.txt.tmpl")},
+			},
+			expected: map[string]*sections.Section{
+				".": {
+					Others: []sections.Page{
+						{
+							URL:      "feed.xml",
+							Source:   "feed.md",
+							Template: "feed.xml.tmpl",
+						},
+						{
+							URL:      "post/index.html",
+							Source:   "post.md",
+							Template: "page.html.tmpl",
+						},
+					},
+					Files: []sections.File{},
+				},
+				"blog": {
+					Others: []sections.Page{
+						{
+							URL:      "blog/index.txt",
+							Source:   "blog/index.md",
+							Template: "robots.txt.tmpl",
+						},
+					},
+					Files: []sections.File{},
+				},
+			},
+		},
+		{
+			name: "can read section config",
+			contentFS: fstest.MapFS{
+				"blog/post.md": &fstest.MapFile{},
+				"blog/_section.json": &fstest.MapFile{
+					Data: []byte(`{"feeds": {"rss": true}}`),
+				},
+			},
+			expected: map[string]*sections.Section{
+				"blog": {
+					Others: []sections.Page{
+						{
+							URL:    "blog/post/index.html",
+							Source: "blog/post.md",
+						},
+					},
+					Files: []sections.File{},
+					Config: config.Section{
+						Feeds: config.Feeds{RSS: true},
+					},
+				},
+			},
+		},
 	}
 
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
 			t.Parallel()
-			actual, err := sections.FromFS(test.contentFS, fakeParseFunc)
+			actual, err := sections.FromFS(test.contentFS, fakeParseFunc, 0)
 			if err != nil {
 				t.Fatal(err)
 			}
@@ -153,6 +229,46 @@ func TestFromFS(t *testing.T) {
 	}
 }
 
+func TestFromFS_Errors(t *testing.T) {
+	t.Parallel()
+
+	contentFS := fstest.MapFS{
+		"index.md":      &fstest.MapFile{},
+		"bad.md":        &fstest.MapFile{Data: []byte("error")},
+		"blog/bad.md":   &fstest.MapFile{Data: []byte("error")},
+		"blog/post1.md": &fstest.MapFile{},
+		"blog/tag.md":   &fstest.MapFile{Data: []byte("badTag")},
+		"blog/_section.json": &fstest.MapFile{
+			Data: []byte(`{"unknown": true}`),
+		},
+	}
+
+	for _, jobs := range []int{1, 4} {
+		actual, err := sections.FromFS(contentFS, fakeParseFunc, jobs)
+		if err == nil {
+			t.Fatal("expected an error but got none")
+		}
+		want := "blog/_section.json: json: unknown field \"unknown\"\n" +
+			"bad.md: bad content\n" +
+			"blog/bad.md: bad content\n" +
+			"blog/tag.md: tags: \"!!!\" must contain a letter or digit"
+		if err.Error() != want {
+			t.Fatalf("expected error %q, got %q", want, err.Error())
+		}
+
+		sources := []string{}
+		for _, dir := range []string{".", "blog"} {
+			for _, page := range actual[dir].Others {
+				sources = append(sources, page.Source)
+			}
+		}
+		wantSources := []string{"index.md", "blog/post1.md"}
+		if !reflect.DeepEqual(sources, wantSources) {
+			t.Fatalf("expected parsed pages %v, got %v", wantSources, sources)
+		}
+	}
+}
+
 func sortPages(pages []sections.Page) {
 	sort.Slice(pages, func(i, j int) bool {
 		return pages[i].URL < pages[j].URL
@@ -233,6 +349,23 @@ func TestPagesSorting(t *testing.T) {
 				{Title: "A"},
 			},
 		},
+		{
+			name: "does not modify the sorted pages",
+			section: func() sections.Pages {
+				pages := sections.Pages{
+					{Title: "B"},
+					{Title: "A"},
+					{Title: "C"},
+				}
+				_ = pages.ByTitle().Reverse()
+				return pages
+			},
+			expected: sections.Pages{
+				{Title: "B"},
+				{Title: "A"},
+				{Title: "C"},
+			},
+		},
 	}
 
 	for _, test := range tests {
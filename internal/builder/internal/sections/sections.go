@@ -1,7 +1,9 @@
 package sections
 
 import (
+	"errors"
 	"fmt"
+	"html/template"
 	"io"
 	"io/fs"
 	"log/slog"
@@ -12,13 +14,34 @@ import (
 	"strings"
 	"time"
 
+	"github.com/fivethirty/satisficer/internal/builder/internal/config"
+	"github.com/fivethirty/satisficer/internal/builder/internal/layout"
 	"github.com/fivethirty/satisficer/internal/builder/internal/markdown"
+	"github.com/fivethirty/satisficer/internal/builder/internal/workers"
 )
 
 type Section struct {
 	Current *Page
 	Others  Pages
 	Files   []File
+	Config  config.Section
+
+	// The fields below are set by NewSite.
+
+	Site *Site
+	// Path is the directory of the section relative to the content
+	// directory. The root of the content directory is ".".
+	Path string
+	// Index is the section's index.md page, or nil if it has none.
+	Index *Page
+	// Pages holds every page in the section other than Index, including
+	// Current.
+	Pages    Pages
+	Parent   *Section
+	Children []*Section
+	// Paginator is only set when rendering the index page of a section
+	// whose config enables pagination.
+	Paginator *Paginator
 }
 type Pages []Page
 
@@ -28,9 +51,35 @@ type Page struct {
 	Title     string
 	CreatedAt time.Time
 	UpdatedAt *time.Time
-	Content   string
-	Template  string
-	UglyURL   bool
+	ExpiresAt *time.Time
+	// Content is the HTML rendered from the page's markdown. It is trusted,
+	// so it is not escaped by templates.
+	Content template.HTML
+	// TOC is the table of contents of the page, a tree of its headings, and
+	// TOCHTML renders it as nested lists of links.
+	TOC     []markdown.Heading
+	TOCHTML template.HTML
+	// Summary is a short form of Content for listings: the content before a
+	// <!--more--> marker, the summary or description in the front matter or
+	// the first words of the text.
+	Summary template.HTML
+	// Links holds the links in Content to other markdown files, which the
+	// builder points to the pages rendered from them.
+	Links []markdown.Link
+	// Plain is the text of the page without markup, for uses such as meta
+	// descriptions. ReadingTime is in minutes, rounded up.
+	Plain       string
+	WordCount   int
+	ReadingTime int
+	Template    string
+	UglyURL     bool
+	Draft       bool
+	NoSitemap   bool
+	Tags        []string
+	Categories  []string
+	// Params holds the front matter fields that are not fields of Page, such
+	// as {{ .Current.Params.author }}.
+	Params map[string]any
 }
 
 type File struct {
@@ -39,8 +88,15 @@ type File struct {
 
 type ParseFunc func(io.Reader) (*markdown.ParsedFile, error)
 
-func FromFS(contentFS fs.FS, parse ParseFunc) (map[string]*Section, error) {
+// FromFS parses every markdown file in contentFS using up to jobs workers.
+// Pages and files are ordered as they are found walking contentFS, no matter
+// which worker finished parsing them first. If any file fails to parse, the
+// returned error joins the errors of every such file, each prefixed with the
+// file's path, and the returned sections hold the pages that did parse.
+func FromFS(contentFS fs.FS, parse ParseFunc, jobs int) (map[string]*Section, error) {
 	sections := make(map[string]*Section)
+	markdownPaths := []string{}
+	configErrs := []error{}
 	err := fs.WalkDir(contentFS, ".", func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
@@ -60,6 +116,16 @@ func FromFS(contentFS fs.FS, parse ParseFunc) (map[string]*Section, error) {
 			}
 		}
 
+		if filepath.Base(path) == config.SectionFile {
+			c, err := readSectionConfig(contentFS, path)
+			if err != nil {
+				configErrs = append(configErrs, fmt.Errorf("%s: %w", path, err))
+				return nil
+			}
+			sections[dir].Config = *c
+			return nil
+		}
+
 		if !strings.HasSuffix(path, ".md") {
 			sections[dir].Files = append(sections[dir].Files, File{
 				URL: path,
@@ -67,48 +133,126 @@ func FromFS(contentFS fs.FS, parse ParseFunc) (map[string]*Section, error) {
 			return nil
 		}
 
-		file, err := contentFS.Open(path)
+		markdownPaths = append(markdownPaths, path)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse content: %w", err)
+	}
+
+	pages := make([]*Page, len(markdownPaths))
+	errs := workers.Run(jobs, len(markdownPaths), func(i int) error {
+		page, err := parsePage(contentFS, markdownPaths[i], parse)
 		if err != nil {
-			return err
+			return withPath(markdownPaths[i], err)
 		}
-		defer func() { _ = file.Close() }()
+		pages[i] = page
+		return nil
+	})
 
-		parsed, err := parse(file)
-		if err != nil {
-			return err
+	for _, page := range pages {
+		if page == nil {
+			continue
 		}
+		dir := filepath.Dir(page.Source)
+		sections[dir].Others = append(sections[dir].Others, *page)
+	}
+	return sections, errors.Join(append(configErrs, errs...)...)
+}
+
+func readSectionConfig(contentFS fs.FS, path string) (*config.Section, error) {
+	file, err := contentFS.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+	return config.ParseSection(file)
+}
 
-		page := Page{
-			URL:       url(path, parsed.FrontMatter.UglyURL),
-			Source:    path,
-			Title:     parsed.FrontMatter.Title,
-			CreatedAt: parsed.FrontMatter.CreatedAt,
-			UpdatedAt: parsed.FrontMatter.UpdatedAt,
-			Content:   parsed.HTML,
-			Template:  parsed.FrontMatter.Template,
-			UglyURL:   parsed.FrontMatter.UglyURL,
+func withPath(path string, err error) error {
+	var posErr *markdown.PositionError
+	if errors.As(err, &posErr) {
+		return &markdown.PositionError{
+			Path:   path,
+			Line:   posErr.Line,
+			Column: posErr.Column,
+			Err:    posErr.Err,
 		}
+	}
+	return fmt.Errorf("%s: %w", path, err)
+}
 
-		sections[dir].Others = append(sections[dir].Others, page)
+func parsePage(contentFS fs.FS, path string, parse ParseFunc) (*Page, error) {
+	file, err := contentFS.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
 
-		return nil
-	})
+	parsed, err := parse(file)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse content: %w", err)
+		return nil, err
+	}
+	for _, warning := range parsed.Warnings {
+		slog.Warn("Rendering code block without highlighting", "path", path, "error", warning)
+	}
+
+	page := &Page{
+		URL:         url(path, parsed.FrontMatter.UglyURL, parsed.FrontMatter.Template),
+		Source:      path,
+		Title:       parsed.FrontMatter.Title,
+		CreatedAt:   parsed.FrontMatter.CreatedAt,
+		UpdatedAt:   parsed.FrontMatter.UpdatedAt,
+		ExpiresAt:   parsed.FrontMatter.ExpiresAt,
+		Content:     parsed.HTML,
+		TOC:         parsed.TOC,
+		TOCHTML:     parsed.TOCHTML,
+		Summary:     parsed.Summary,
+		Links:       parsed.Links,
+		Plain:       parsed.Plain,
+		WordCount:   parsed.WordCount,
+		ReadingTime: parsed.ReadingTime,
+		Template:    parsed.FrontMatter.Template,
+		UglyURL:     parsed.FrontMatter.UglyURL,
+		Draft:       parsed.FrontMatter.Draft,
+		NoSitemap:   parsed.FrontMatter.NoSitemap,
+		Tags:        parsed.FrontMatter.Tags,
+		Categories:  parsed.FrontMatter.Categories,
+		Params:      parsed.FrontMatter.Params,
 	}
-	return sections, nil
+	for _, taxonomy := range TaxonomyNames {
+		for _, term := range page.terms(taxonomy) {
+			if markdown.Slugify(term) == "" {
+				return nil, fmt.Errorf("%s: %q must contain a letter or digit", taxonomy, term)
+			}
+		}
+	}
+	return page, nil
 }
 
-func url(filePath string, uglyURL bool) string {
+// SetTemplate sets the template that renders p, which decides the extension of
+// its URL.
+func (p *Page) SetTemplate(name string) {
+	p.Template = name
+	p.URL = url(p.Source, p.UglyURL, name)
+}
+
+// url returns where the page at filePath is rendered. Pages rendered with
+// templates for formats other than HTML always get ugly URLs, e.g. feed.xml.
+func url(filePath string, uglyURL bool, template string) string {
 	trimmed := strings.TrimSuffix(filePath, ".md")
-	if path.Base(filePath) == "index.md" || uglyURL {
-		return fmt.Sprintf("%s.html", trimmed)
+	ext := layout.Ext(template)
+	if path.Base(filePath) == "index.md" || uglyURL || ext != ".html" {
+		return fmt.Sprintf("%s%s", trimmed, ext)
 	} else {
 		return path.Join(trimmed, "index.html")
 	}
 }
 
+// ByTitle, ByCreatedAt and Reverse return sorted copies of p, so that
+// templates rendered concurrently can sort the same pages.
 func (p Pages) ByTitle() Pages {
+	p = slices.Clone(p)
 	sort.SliceStable(p, func(i, j int) bool {
 		return p[i].Title < p[j].Title
 	})
@@ -116,6 +260,7 @@ func (p Pages) ByTitle() Pages {
 }
 
 func (p Pages) ByCreatedAt() Pages {
+	p = slices.Clone(p)
 	sort.SliceStable(p, func(i, j int) bool {
 		return p[i].CreatedAt.Before(p[j].CreatedAt)
 	})
@@ -123,10 +268,31 @@ func (p Pages) ByCreatedAt() Pages {
 }
 
 func (p Pages) Reverse() Pages {
+	p = slices.Clone(p)
 	slices.Reverse(p)
 	return p
 }
 
+// Ancestors returns the sections above s, starting from the root.
+func (s *Section) Ancestors() []*Section {
+	ancestors := []*Section{}
+	for p := s.Parent; p != nil; p = p.Parent {
+		ancestors = append(ancestors, p)
+	}
+	slices.Reverse(ancestors)
+	return ancestors
+}
+
+// PagesRecursive returns Pages of s and of every section below it. Index
+// pages are left out.
+func (s *Section) PagesRecursive() Pages {
+	pages := slices.Clone(s.Pages)
+	for _, child := range s.Children {
+		pages = append(pages, child.PagesRecursive()...)
+	}
+	return pages
+}
+
 func (s *Section) ForPage(page *Page) *Section {
 	otherPages := make(Pages, 0, len(s.Others))
 	for _, p := range s.Others {
@@ -136,8 +302,15 @@ func (s *Section) ForPage(page *Page) *Section {
 	}
 
 	return &Section{
-		Current: page,
-		Others:  otherPages,
-		Files:   s.Files,
+		Current:  page,
+		Others:   otherPages,
+		Files:    s.Files,
+		Config:   s.Config,
+		Site:     s.Site,
+		Path:     s.Path,
+		Index:    s.Index,
+		Pages:    s.Pages,
+		Parent:   s.Parent,
+		Children: s.Children,
 	}
 }
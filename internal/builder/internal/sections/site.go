@@ -0,0 +1,92 @@
+package sections
+
+import (
+	"path"
+	"sort"
+	"time"
+
+	"github.com/fivethirty/satisficer/internal/builder/internal/config"
+)
+
+// Site gives templates access to the whole content tree rather than just the
+// section of the page being rendered.
+type Site struct {
+	Title   string
+	BaseURL string
+	// Params holds the params of the project config.
+	Params map[string]any
+	// BuildTime is when the build that rendered the page started.
+	BuildTime time.Time
+	// Pages holds every page of every section, ordered by source path.
+	Pages Pages
+	// Sections holds every section keyed by its directory relative to the
+	// content directory. The root of the content directory is ".".
+	Sections map[string]*Section
+	// Taxonomies holds a Taxonomy for each of TaxonomyNames.
+	Taxonomies map[string]*Taxonomy
+	// Data holds the contents of the files in the data directory. It is set
+	// by the builder rather than NewSite.
+	Data map[string]any
+}
+
+// NewSite creates the Site of sections, linking every section to it and to
+// its parent and child sections. Sections are added for directories that
+// only hold other directories, so that every section but the root has a
+// parent. It must be called once the pages that are not published have been
+// removed from sections.
+func NewSite(sections map[string]*Section, cfg *config.Config, buildTime time.Time) *Site {
+	site := &Site{
+		Title:     cfg.Title,
+		BaseURL:   cfg.BaseURL,
+		Params:    cfg.Params,
+		BuildTime: buildTime,
+		Pages:     Pages{},
+		Sections:  sections,
+	}
+
+	dirs := make([]string, 0, len(sections))
+	for dir := range sections {
+		dirs = append(dirs, dir)
+	}
+	for _, dir := range dirs {
+		for dir != "." {
+			dir = path.Dir(dir)
+			if _, ok := sections[dir]; !ok {
+				sections[dir] = &Section{Others: Pages{}, Files: []File{}}
+			}
+		}
+	}
+
+	dirs = dirs[:0]
+	for dir := range sections {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	for _, dir := range dirs {
+		sections[dir].Children = []*Section{}
+	}
+	for _, dir := range dirs {
+		s := sections[dir]
+		s.Site = site
+		s.Path = dir
+		s.Index = nil
+		s.Pages = make(Pages, 0, len(s.Others))
+		for i, page := range s.Others {
+			if page.Source == path.Join(dir, "index.md") {
+				s.Index = &s.Others[i]
+			} else {
+				s.Pages = append(s.Pages, page)
+			}
+		}
+		if dir != "." {
+			s.Parent = sections[path.Dir(dir)]
+			s.Parent.Children = append(s.Parent.Children, s)
+		}
+		site.Pages = append(site.Pages, s.Others...)
+	}
+	sort.Slice(site.Pages, func(i, j int) bool {
+		return site.Pages[i].Source < site.Pages[j].Source
+	})
+	site.Taxonomies = newTaxonomies(site.Pages)
+	return site
+}
@@ -0,0 +1,180 @@
+package sections_test
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/fivethirty/satisficer/internal/builder/internal/config"
+	"github.com/fivethirty/satisficer/internal/builder/internal/sections"
+)
+
+func TestNewSite(t *testing.T) {
+	t.Parallel()
+
+	s := map[string]*sections.Section{
+		".": {
+			Others: sections.Pages{{Source: "index.md"}, {Source: "about.md"}},
+		},
+		"posts": {
+			Others: sections.Pages{{Source: "posts/b.md"}, {Source: "posts/a.md"}},
+		},
+		"empty": {},
+	}
+	cfg := &config.Config{Title: "My Site", BaseURL: "https://example.com"}
+	buildTime := time.Date(2025, 5, 13, 0, 0, 0, 0, time.UTC)
+
+	site := sections.NewSite(s, cfg, buildTime)
+
+	if site.Title != cfg.Title || site.BaseURL != cfg.BaseURL {
+		t.Fatalf("expected title and base url from config, got %q and %q", site.Title, site.BaseURL)
+	}
+	if !site.BuildTime.Equal(buildTime) {
+		t.Fatalf("expected build time %v, got %v", buildTime, site.BuildTime)
+	}
+	wantSources := []string{"about.md", "index.md", "posts/a.md", "posts/b.md"}
+	if len(site.Pages) != len(wantSources) {
+		t.Fatalf("expected %d pages, got %d", len(wantSources), len(site.Pages))
+	}
+	for i, page := range site.Pages {
+		if page.Source != wantSources[i] {
+			t.Fatalf("expected page %d to be %s, got %s", i, wantSources[i], page.Source)
+		}
+	}
+	for dir, section := range s {
+		if site.Sections[dir] != section {
+			t.Fatalf("expected section %q in site", dir)
+		}
+		if section.Site != site {
+			t.Fatalf("expected section %q to have site set", dir)
+		}
+		if section.ForPage(&sections.Page{}).Site != site {
+			t.Fatalf("expected section %q to keep site for pages", dir)
+		}
+	}
+}
+
+func TestNewSite_Hierarchy(t *testing.T) {
+	t.Parallel()
+
+	s := map[string]*sections.Section{
+		".": {
+			Others: sections.Pages{{Source: "index.md"}, {Source: "about.md"}},
+		},
+		"blog": {
+			Others: sections.Pages{{Source: "blog/post.md"}, {Source: "blog/index.md"}},
+		},
+		"blog/2025": {
+			Others: sections.Pages{{Source: "blog/2025/new.md"}},
+		},
+		"docs/guides": {
+			Others: sections.Pages{{Source: "docs/guides/setup.md"}},
+		},
+	}
+
+	sections.NewSite(s, &config.Config{}, time.Time{})
+
+	tests := []struct {
+		dir           string
+		wantIndex     string
+		wantPages     []string
+		wantParent    string
+		wantChildren  []string
+		wantAncestors []string
+		wantRecursive []string
+	}{
+		{
+			dir:          ".",
+			wantIndex:    "index.md",
+			wantPages:    []string{"about.md"},
+			wantChildren: []string{"blog", "docs"},
+			wantRecursive: []string{
+				"about.md",
+				"blog/post.md",
+				"blog/2025/new.md",
+				"docs/guides/setup.md",
+			},
+		},
+		{
+			dir:           "blog",
+			wantIndex:     "blog/index.md",
+			wantPages:     []string{"blog/post.md"},
+			wantParent:    ".",
+			wantChildren:  []string{"blog/2025"},
+			wantAncestors: []string{"."},
+			wantRecursive: []string{"blog/post.md", "blog/2025/new.md"},
+		},
+		{
+			dir:           "blog/2025",
+			wantPages:     []string{"blog/2025/new.md"},
+			wantParent:    "blog",
+			wantAncestors: []string{".", "blog"},
+			wantRecursive: []string{"blog/2025/new.md"},
+		},
+		{
+			dir:           "docs",
+			wantPages:     []string{},
+			wantParent:    ".",
+			wantChildren:  []string{"docs/guides"},
+			wantAncestors: []string{"."},
+			wantRecursive: []string{"docs/guides/setup.md"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.dir, func(t *testing.T) {
+			t.Parallel()
+
+			section, ok := s[test.dir]
+			if !ok {
+				t.Fatalf("expected section %q", test.dir)
+			}
+			if section.Path != test.dir {
+				t.Fatalf("expected path %q, got %q", test.dir, section.Path)
+			}
+
+			index := ""
+			if section.Index != nil {
+				index = section.Index.Source
+			}
+			if index != test.wantIndex {
+				t.Fatalf("expected index %q, got %q", test.wantIndex, index)
+			}
+
+			parent := ""
+			if section.Parent != nil {
+				parent = section.Parent.Path
+			}
+			if parent != test.wantParent {
+				t.Fatalf("expected parent %q, got %q", test.wantParent, parent)
+			}
+
+			assertSources(t, "pages", section.Pages, test.wantPages)
+			assertSources(t, "recursive pages", section.PagesRecursive(), test.wantRecursive)
+			assertPaths(t, "children", section.Children, test.wantChildren)
+			assertPaths(t, "ancestors", section.Ancestors(), test.wantAncestors)
+		})
+	}
+}
+
+func assertSources(t *testing.T, name string, pages sections.Pages, want []string) {
+	t.Helper()
+	got := make([]string, 0, len(pages))
+	for _, page := range pages {
+		got = append(got, page.Source)
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("expected %s %v, got %v", name, want, got)
+	}
+}
+
+func assertPaths(t *testing.T, name string, s []*sections.Section, want []string) {
+	t.Helper()
+	got := make([]string, 0, len(s))
+	for _, section := range s {
+		got = append(got, section.Path)
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("expected %s %v, got %v", name, want, got)
+	}
+}
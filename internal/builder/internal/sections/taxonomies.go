@@ -0,0 +1,101 @@
+package sections
+
+import (
+	"path"
+	"sort"
+
+	"github.com/fivethirty/satisficer/internal/builder/internal/markdown"
+)
+
+// TaxonomyNames are the front matter fields pages can be grouped by.
+var TaxonomyNames = []string{"tags", "categories"}
+
+// Taxonomy groups pages by the terms they list in the front matter field
+// Name.
+type Taxonomy struct {
+	Name string
+	// URL is where the overview of every term is generated.
+	URL string
+	// Terms holds every term used by a page, ordered by slug.
+	Terms []*Term
+}
+
+type Term struct {
+	// Name is the term as written in the front matter of the first page,
+	// ordered by source path, that uses it.
+	Name string
+	Slug string
+	// URL is where the listing of the term's pages is generated.
+	URL string
+	// Pages holds every page that uses the term, ordered by source path.
+	Pages Pages
+}
+
+// TaxonomyPage is the data passed to the templates of pages generated for a
+// taxonomy. Term is nil for the overview of the taxonomy.
+type TaxonomyPage struct {
+	Taxonomy *Taxonomy
+	Term     *Term
+	Site     *Site
+}
+
+// Term returns the term with the same slug as name, or nil if no page uses
+// it.
+func (t *Taxonomy) Term(name string) *Term {
+	slug := markdown.Slugify(name)
+	for _, term := range t.Terms {
+		if term.Slug == slug {
+			return term
+		}
+	}
+	return nil
+}
+
+func (p *Page) terms(taxonomy string) []string {
+	switch taxonomy {
+	case "tags":
+		return p.Tags
+	case "categories":
+		return p.Categories
+	}
+	return nil
+}
+
+// newTaxonomies groups pages, which must be ordered by source path, by each
+// of TaxonomyNames. Terms whose slugs are the same are merged.
+func newTaxonomies(pages Pages) map[string]*Taxonomy {
+	taxonomies := make(map[string]*Taxonomy, len(TaxonomyNames))
+	for _, name := range TaxonomyNames {
+		t := &Taxonomy{
+			Name:  name,
+			URL:   path.Join(name, "index.html"),
+			Terms: []*Term{},
+		}
+		terms := make(map[string]*Term)
+		for _, page := range pages {
+			for _, name := range page.terms(t.Name) {
+				slug := markdown.Slugify(name)
+				term, ok := terms[slug]
+				if !ok {
+					term = &Term{
+						Name:  name,
+						Slug:  slug,
+						URL:   path.Join(t.Name, slug, "index.html"),
+						Pages: Pages{},
+					}
+					terms[slug] = term
+					t.Terms = append(t.Terms, term)
+				}
+				// A page listing the same term twice is only added once.
+				if n := len(term.Pages); n == 0 || term.Pages[n-1].Source != page.Source {
+					term.Pages = append(term.Pages, page)
+				}
+			}
+		}
+		sort.Slice(t.Terms, func(i, j int) bool {
+			return t.Terms[i].Slug < t.Terms[j].Slug
+		})
+		taxonomies[name] = t
+	}
+	return taxonomies
+}
@@ -0,0 +1,69 @@
+package sections_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fivethirty/satisficer/internal/builder/internal/config"
+	"github.com/fivethirty/satisficer/internal/builder/internal/sections"
+)
+
+func TestNewSite_Taxonomies(t *testing.T) {
+	t.Parallel()
+
+	s := map[string]*sections.Section{
+		".": {
+			Others: sections.Pages{
+				{Source: "b.md", Tags: []string{"Go", "web"}},
+				{Source: "a.md", Tags: []string{"go", "go"}, Categories: []string{"Notes"}},
+				{Source: "c.md"},
+			},
+		},
+	}
+
+	site := sections.NewSite(s, &config.Config{}, time.Time{})
+
+	tags := site.Taxonomies["tags"]
+	if tags.URL != "tags/index.html" {
+		t.Fatalf("expected tags url tags/index.html, got %s", tags.URL)
+	}
+	assertTerms(t, tags, []wantTerm{
+		{name: "go", url: "tags/go/index.html", sources: []string{"a.md", "b.md"}},
+		{name: "web", url: "tags/web/index.html", sources: []string{"b.md"}},
+	})
+	assertTerms(t, site.Taxonomies["categories"], []wantTerm{
+		{name: "Notes", url: "categories/notes/index.html", sources: []string{"a.md"}},
+	})
+
+	if term := tags.Term("GO"); term == nil || term.Slug != "go" {
+		t.Fatalf("expected to find term go, got %v", term)
+	}
+	if term := tags.Term("rust"); term != nil {
+		t.Fatalf("expected no term rust, got %v", term)
+	}
+}
+
+type wantTerm struct {
+	name    string
+	url     string
+	sources []string
+}
+
+func assertTerms(t *testing.T, taxonomy *sections.Taxonomy, want []wantTerm) {
+	t.Helper()
+	if len(taxonomy.Terms) != len(want) {
+		t.Fatalf("expected %d %s, got %d", len(want), taxonomy.Name, len(taxonomy.Terms))
+	}
+	for i, term := range taxonomy.Terms {
+		if term.Name != want[i].name || term.URL != want[i].url {
+			t.Fatalf(
+				"expected term %s at %s, got %s at %s",
+				want[i].name,
+				want[i].url,
+				term.Name,
+				term.URL,
+			)
+		}
+		assertSources(t, term.Name, term.Pages, want[i].sources)
+	}
+}
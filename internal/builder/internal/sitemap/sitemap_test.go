@@ -0,0 +1,72 @@
+package sitemap_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/fivethirty/satisficer/internal/builder/internal/sitemap"
+)
+
+func TestWrite(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		urls []sitemap.URL
+		want string
+	}{
+		{
+			name: "writes urls with and without lastmod",
+			urls: []sitemap.URL{
+				{
+					Loc:     "https://example.com/",
+					LastMod: time.Date(2025, 5, 13, 12, 0, 0, 0, time.UTC),
+				},
+				{
+					Loc:     "https://example.com/posts/a&b/",
+					LastMod: time.Date(2025, 5, 14, 14, 0, 0, 0, time.FixedZone("", 2*60*60)),
+				},
+				{
+					Loc: "https://example.com/about/",
+				},
+			},
+			want: `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <url>
+    <loc>https://example.com/</loc>
+    <lastmod>2025-05-13T12:00:00Z</lastmod>
+  </url>
+  <url>
+    <loc>https://example.com/posts/a&amp;b/</loc>
+    <lastmod>2025-05-14T12:00:00Z</lastmod>
+  </url>
+  <url>
+    <loc>https://example.com/about/</loc>
+  </url>
+</urlset>
+`,
+		},
+		{
+			name: "writes an empty sitemap",
+			urls: nil,
+			want: `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9"></urlset>
+`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			buf := &bytes.Buffer{}
+			if err := sitemap.Write(buf, test.urls); err != nil {
+				t.Fatal(err)
+			}
+			if buf.String() != test.want {
+				t.Fatalf("got:\n%s\nwant:\n%s", buf.String(), test.want)
+			}
+		})
+	}
+}
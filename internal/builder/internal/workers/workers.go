@@ -0,0 +1,36 @@
+package workers
+
+import (
+	"runtime"
+	"sync"
+)
+
+// Run calls fn for every index in [0, n) using at most jobs goroutines. If
+// jobs is less than one, GOMAXPROCS goroutines are used. The returned slice
+// holds the error returned for each index, so callers can report errors in
+// a deterministic order regardless of which goroutine finished first.
+func Run(jobs int, n int, fn func(i int) error) []error {
+	if jobs < 1 {
+		jobs = runtime.GOMAXPROCS(0)
+	}
+	jobs = min(jobs, n)
+
+	errs := make([]error, n)
+	indices := make(chan int)
+	var wg sync.WaitGroup
+	wg.Add(jobs)
+	for range jobs {
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				errs[i] = fn(i)
+			}
+		}()
+	}
+	for i := range n {
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+	return errs
+}
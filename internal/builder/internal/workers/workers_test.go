@@ -0,0 +1,117 @@
+package workers_test
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fivethirty/satisficer/internal/builder/internal/workers"
+)
+
+func TestRun(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		jobs int
+		n    int
+	}{
+		{
+			name: "no work",
+			jobs: 4,
+			n:    0,
+		},
+		{
+			name: "single worker",
+			jobs: 1,
+			n:    10,
+		},
+		{
+			name: "more work than workers",
+			jobs: 3,
+			n:    50,
+		},
+		{
+			name: "more workers than work",
+			jobs: 10,
+			n:    3,
+		},
+		{
+			name: "default number of workers",
+			jobs: 0,
+			n:    20,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			var (
+				mu      sync.Mutex
+				seen    = make([]bool, test.n)
+				running atomic.Int32
+				peak    atomic.Int32
+			)
+			errs := workers.Run(test.jobs, test.n, func(i int) error {
+				current := running.Add(1)
+				defer running.Add(-1)
+				for {
+					p := peak.Load()
+					if current <= p || peak.CompareAndSwap(p, current) {
+						break
+					}
+				}
+				time.Sleep(time.Millisecond)
+				mu.Lock()
+				defer mu.Unlock()
+				seen[i] = true
+				return nil
+			})
+
+			if len(errs) != test.n {
+				t.Fatalf("expected %d errors, got %d", test.n, len(errs))
+			}
+			if err := errors.Join(errs...); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for i, ok := range seen {
+				if !ok {
+					t.Fatalf("index %d was never processed", i)
+				}
+			}
+			if test.jobs > 0 && int(peak.Load()) > test.jobs {
+				t.Fatalf("expected at most %d concurrent calls, got %d", test.jobs, peak.Load())
+			}
+		})
+	}
+}
+
+func TestRun_ErrorsInIndexOrder(t *testing.T) {
+	t.Parallel()
+
+	errs := workers.Run(4, 10, func(i int) error {
+		if i%3 == 0 {
+			// Finish later indices first to make sure ordering does not depend
+			// on completion order.
+			time.Sleep(time.Duration(10-i) * time.Millisecond)
+			return fmt.Errorf("error %d", i)
+		}
+		return nil
+	})
+
+	got := []string{}
+	for _, err := range errs {
+		if err != nil {
+			got = append(got, err.Error())
+		}
+	}
+	want := []string{"error 0", "error 3", "error 6", "error 9"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected errors %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,103 @@
+package builder
+
+import (
+	"fmt"
+	"io/fs"
+	"net/url"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/fivethirty/satisficer/internal/builder/internal/config"
+	"github.com/fivethirty/satisficer/internal/builder/internal/markdown"
+	"github.com/fivethirty/satisficer/internal/builder/internal/sections"
+)
+
+// resolveLinks points the links of every page to other markdown files at the
+// pages rendered from them, relative to the root of the domain like relURL,
+// by parsing such pages again with parse. It must run once every page has
+// its final URL. Links to markdown files that are not rendered are reported
+// as errors and left as they are.
+func (b *Builder) resolveLinks(
+	s map[string]*sections.Section,
+	cfg *config.Config,
+	parse parseFunc,
+) []error {
+	pageURLs := map[string]string{}
+	dirs := make([]string, 0, len(s))
+	for dir, section := range s {
+		dirs = append(dirs, dir)
+		for _, page := range section.Others {
+			pageURLs[page.Source] = page.URL
+		}
+	}
+	sort.Strings(dirs)
+	basePath := ""
+	if u, err := url.Parse(cfg.BaseURL); err == nil {
+		basePath = strings.TrimSuffix(u.Path, "/")
+	}
+
+	errs := []error{}
+	for _, dir := range dirs {
+		section := s[dir]
+		for i := range section.Others {
+			page := &section.Others[i]
+			if len(page.Links) == 0 {
+				continue
+			}
+			// The links are shared with the cached parse of the page.
+			page.Links = slices.Clone(page.Links)
+			urls := make(map[string]string, len(page.Links))
+			for j := range page.Links {
+				link := &page.Links[j]
+				target := path.Join(path.Dir(page.Source), link.Path)
+				u, ok := pageURLs[target]
+				if !ok {
+					errs = append(errs, b.linkError(page.Source, target, link))
+					continue
+				}
+				linkURL := &url.URL{Path: permalink(basePath, u), Fragment: link.Fragment}
+				link.URL = linkURL.String()
+				urls[link.Dest] = link.URL
+			}
+			if len(urls) == 0 {
+				continue
+			}
+			parsed, err := b.parseWithURLs(page.Source, parse, urls)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", page.Source, err))
+				continue
+			}
+			page.Content = parsed.HTML
+			page.Summary = parsed.Summary
+		}
+	}
+	return errs
+}
+
+func (b *Builder) parseWithURLs(
+	source string,
+	parse parseFunc,
+	urls map[string]string,
+) (*markdown.ParsedFile, error) {
+	f, err := b.contentFS.Open(source)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+	return parse(f, urls)
+}
+
+func (b *Builder) linkError(source string, target string, link *markdown.Link) error {
+	reason := "does not exist"
+	if _, err := fs.Stat(b.contentFS, target); err == nil {
+		reason = "is not published"
+	}
+	return &markdown.PositionError{
+		Path:   source,
+		Line:   link.Line,
+		Column: link.Column,
+		Err:    fmt.Errorf("link to %s: %s %s", link.Dest, target, reason),
+	}
+}
@@ -0,0 +1,63 @@
+package builder
+
+import (
+	"io"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fivethirty/satisficer/internal/builder/internal/config"
+	"github.com/fivethirty/satisficer/internal/builder/internal/manifest"
+	"github.com/fivethirty/satisficer/internal/builder/internal/sections"
+	"github.com/fivethirty/satisficer/internal/builder/internal/sitemap"
+)
+
+const SitemapFile = "sitemap.xml"
+
+// addSitemap adds a sitemap of every HTML page in every section, other than
+// those that opt out in their front matter, when it is enabled in the config.
+func (b *Builder) addSitemap(s map[string]*sections.Section, cfg *config.Config, bd *build) {
+	if !cfg.Sitemap {
+		return
+	}
+
+	urls := []sitemap.URL{}
+	for _, section := range s {
+		for _, page := range section.Others {
+			if page.NoSitemap || !strings.HasSuffix(page.URL, ".html") {
+				continue
+			}
+			lastMod := page.CreatedAt
+			if page.UpdatedAt != nil {
+				lastMod = *page.UpdatedAt
+			}
+			urls = append(urls, sitemap.URL{
+				Loc:     permalink(cfg.BaseURL, page.URL),
+				LastMod: lastMod,
+			})
+		}
+	}
+	sort.Slice(urls, func(i, j int) bool {
+		return urls[i].Loc < urls[j].Loc
+	})
+
+	// The sitemap only depends on the URLs it lists, so that editing the
+	// content of a page does not rewrite it.
+	parts := make([]string, 0, 2*len(urls)+2)
+	parts = append(parts, version, "sitemap")
+	for _, u := range urls {
+		parts = append(parts, u.Loc, u.LastMod.UTC().Format(time.RFC3339))
+	}
+
+	bd.add(output{
+		path: SitemapFile,
+		hash: manifest.Hash(parts...),
+		write: func(dest string) error {
+			slog.Info("Generating sitemap", "path", SitemapFile)
+			return writeOutput(dest, func(w io.Writer) error {
+				return sitemap.Write(w, urls)
+			})
+		},
+	})
+}
@@ -0,0 +1,88 @@
+package builder
+
+import (
+	"fmt"
+	"log/slog"
+	"path"
+
+	"github.com/fivethirty/satisficer/internal/builder/internal/layout"
+	"github.com/fivethirty/satisficer/internal/builder/internal/manifest"
+	"github.com/fivethirty/satisficer/internal/builder/internal/sections"
+)
+
+const (
+	TaxonomyTemplate = "taxonomy.html.tmpl"
+	TermTemplate     = "term.html.tmpl"
+)
+
+// addTaxonomies adds an overview page for every taxonomy and a listing page
+// for each of its terms. They are rendered with the taxonomy's own templates
+// in a layout directory of the same name, such as tags/term.html.tmpl, or
+// with the templates at the root of the layout shared by all taxonomies.
+// Taxonomies without templates are skipped. pageURLs maps the URL of every
+// page to its source, so that pages that would overwrite content are
+// reported instead.
+func (b *Builder) addTaxonomies(
+	site *sections.Site,
+	l *layout.Layout,
+	hashes *contentHashes,
+	pageURLs map[string]string,
+	bd *build,
+) {
+	for _, name := range sections.TaxonomyNames {
+		taxonomy := site.Taxonomies[name]
+		taxonomyTmpl := taxonomyTemplate(l, name, TaxonomyTemplate)
+		termTmpl := taxonomyTemplate(l, name, TermTemplate)
+		if taxonomyTmpl == nil && termTmpl == nil {
+			if len(taxonomy.Terms) > 0 {
+				slog.Info("No taxonomy templates found, skipping...", "taxonomy", name)
+			}
+			continue
+		}
+
+		add := func(url string, tmpl layout.Template, term *sections.Term) {
+			if tmpl == nil {
+				return
+			}
+			if source, ok := pageURLs[url]; ok {
+				bd.errs = append(bd.errs, fmt.Errorf(
+					"%s: %s page conflicts with %s",
+					url,
+					name,
+					source,
+				))
+				return
+			}
+			data := &sections.TaxonomyPage{Taxonomy: taxonomy, Term: term, Site: site}
+			bd.add(output{
+				path: url,
+				hash: manifest.Hash(
+					version,
+					"taxonomy",
+					url,
+					hashes.site,
+					l.Fingerprint(tmpl.Name()),
+				),
+				write: func(dest string) error {
+					slog.Info("Generating taxonomy page", "path", url, "taxonomy", name)
+					if err := writeContent(tmpl, data, dest); err != nil {
+						return fmt.Errorf("%s: %w", url, err)
+					}
+					return nil
+				},
+			})
+		}
+
+		add(taxonomy.URL, taxonomyTmpl, nil)
+		for _, term := range taxonomy.Terms {
+			add(term.URL, termTmpl, term)
+		}
+	}
+}
+
+func taxonomyTemplate(l *layout.Layout, taxonomy string, name string) layout.Template {
+	if tmpl := l.Lookup(path.Join(taxonomy, name)); tmpl != nil {
+		return tmpl
+	}
+	return l.Lookup(name)
+}
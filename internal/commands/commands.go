@@ -6,12 +6,16 @@ import (
 	"flag"
 	"fmt"
 	"io"
+	"log/slog"
 	"os"
+	"path/filepath"
 	"runtime/debug"
 	"strings"
 
 	"github.com/fivethirty/satisficer/internal/builder"
 	"github.com/fivethirty/satisficer/internal/creator"
+	"github.com/fivethirty/satisficer/internal/fsutil"
+	"github.com/fivethirty/satisficer/internal/highlight"
 	"github.com/fivethirty/satisficer/internal/server"
 )
 
@@ -112,6 +116,16 @@ var SubCommands = map[string]*Command{
 	}(),
 	"build": func() *Command {
 		fs := flagSet("build")
+		var jobs uint
+		fs.UintVar(&jobs, "jobs", 0, "")
+		fs.UintVar(&jobs, "j", 0, "")
+		var drafts, future bool
+		fs.BoolVar(&drafts, "drafts", false, "")
+		fs.BoolVar(&future, "future", false, "")
+		var cache string
+		fs.StringVar(&cache, "cache-dir", "", "")
+		var noCache bool
+		fs.BoolVar(&noCache, "no-cache", false, "")
 		c := &Command{
 			UsageText: readUsageText("usage/build.txt"),
 			FlagSet:   fs,
@@ -122,7 +136,12 @@ var SubCommands = map[string]*Command{
 		c.Run = func() error {
 			projectFS := os.DirFS(fs.Arg(0))
 			buildDir := fs.Arg(1)
-			b, err := builder.New(projectFS)
+			b, err := builder.New(projectFS, builder.Options{
+				CacheDir: cacheDir(cache, noCache),
+				Jobs:     int(jobs),
+				Drafts:   drafts,
+				Future:   future,
+			})
 			if err != nil {
 				return err
 			}
@@ -134,8 +153,14 @@ var SubCommands = map[string]*Command{
 	"serve": func() *Command {
 		fs := flagSet("serve")
 		var port uint
-		fs.UintVar(&port, "port", 3000, "")
-		fs.UintVar(&port, "p", 3000, "")
+		fs.UintVar(&port, "port", 0, "")
+		fs.UintVar(&port, "p", 0, "")
+		var jobs uint
+		fs.UintVar(&jobs, "jobs", 0, "")
+		fs.UintVar(&jobs, "j", 0, "")
+		var noDrafts, future bool
+		fs.BoolVar(&noDrafts, "no-drafts", false, "")
+		fs.BoolVar(&future, "future", false, "")
 		c := &Command{
 			UsageText: readUsageText("usage/serve.txt"),
 			FlagSet:   fs,
@@ -146,10 +171,61 @@ var SubCommands = map[string]*Command{
 		c.Run = func() error {
 			projectFS := os.DirFS(fs.Arg(0))
 			port := uint16(port)
-			return server.Serve(projectFS, port)
+			return server.Serve(projectFS, port, builder.Options{
+				Jobs:   int(jobs),
+				Drafts: !noDrafts,
+				Future: future,
+			})
 		}
 		return c
 	}(),
+	"stylesheet": func() *Command {
+		fs := flagSet("stylesheet")
+		var theme string
+		fs.StringVar(&theme, "theme", highlight.DefaultTheme, "")
+		fs.StringVar(&theme, "t", highlight.DefaultTheme, "")
+		c := &Command{
+			UsageText: readUsageText("usage/stylesheet.txt"),
+			FlagSet:   fs,
+		}
+		c.Validate = func() error {
+			return c.verifyArgCount(1)
+		}
+		c.Run = func() error {
+			return writeStylesheet(fs.Arg(0), theme)
+		}
+		return c
+	}(),
+}
+
+func writeStylesheet(path string, theme string) (err error) {
+	f, err := fsutil.CreateFile(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Join(err, f.Close())
+	}()
+	return highlight.WriteStylesheet(f, theme)
+}
+
+// cacheDir returns where build manifests are stored: dir if set, otherwise
+// a directory in the user cache directory. An empty result disables caching
+// between builds.
+func cacheDir(dir string, disabled bool) string {
+	if disabled {
+		slog.Info("Build cache disabled, rebuilding every file")
+		return ""
+	}
+	if dir != "" {
+		return dir
+	}
+	userDir, err := os.UserCacheDir()
+	if err != nil {
+		slog.Warn("Build cache disabled, rebuilding every file", "error", err)
+		return ""
+	}
+	return filepath.Join(userDir, "satisficer")
 }
 
 var version = ""
@@ -119,6 +119,11 @@ func TestHelp(t *testing.T) {
 			args:      []string{"satisficer", "serve"},
 			usagePath: "usage/serve.txt",
 		},
+		{
+			name:      "stylesheet",
+			args:      []string{"satisficer", "stylesheet"},
+			usagePath: "usage/stylesheet.txt",
+		},
 	}
 
 	for _, test := range tests {
@@ -185,7 +190,11 @@ func TestRealCreateAndBuild(t *testing.T) {
 	}
 
 	err = commands.Execute(
-		[]string{"satisficer", "build", projectDir, buildDir},
+		[]string{
+			"satisficer", "build",
+			"--cache-dir", filepath.Join(dir, "cache"),
+			projectDir, buildDir,
+		},
 	)
 	if err != nil {
 		t.Fatalf("expected no error but got %v", err)
@@ -9,7 +9,8 @@ import (
 )
 
 const (
-	dirPerm = 0o750
+	dirPerm  = 0o750
+	filePerm = 0o644
 )
 
 func CopyFS(src fs.FS, destDir string) error {
@@ -34,13 +35,37 @@ func CopyFile(fsys fs.FS, path string, destDir string) error {
 		return err
 	}
 	defer func() { _ = src.Close() }()
-	dest, err := CreateFile(filepath.Join(destDir, path))
+	return WriteFile(filepath.Join(destDir, path), func(w io.Writer) error {
+		_, err := io.Copy(w, src)
+		return err
+	})
+}
+
+// WriteFile replaces the file at path with what write writes. It writes to a
+// temporary file in the same directory and renames it to path, so that
+// readers of path see either the old or the new file but never a partial
+// one, and a failed write leaves the old file in place.
+func WriteFile(path string, write func(w io.Writer) error) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, dirPerm); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
 	if err != nil {
 		return err
 	}
-	defer func() { _ = dest.Close() }()
-	_, err = io.Copy(dest, src)
-	return err
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	err = write(tmp)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), filePerm); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Clean(path))
 }
 
 func CreateFile(path string) (*os.File, error) {
@@ -1,8 +1,11 @@
 package fsutil_test
 
 import (
+	"errors"
+	"io"
 	"io/fs"
 	"os"
+	"path/filepath"
 	"reflect"
 	"sort"
 	"testing"
@@ -60,3 +63,51 @@ func TestCopyFS(t *testing.T) {
 		})
 	}
 }
+
+func TestWriteFile(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a", "index.html")
+	write := func(content string, err error) error {
+		return fsutil.WriteFile(path, func(w io.Writer) error {
+			if _, err := io.WriteString(w, content); err != nil {
+				return err
+			}
+			return err
+		})
+	}
+
+	if err := write("old", nil); err != nil {
+		t.Fatal(err)
+	}
+	failed := errors.New("failed")
+	if err := write("partial", failed); !errors.Is(err, failed) {
+		t.Fatalf("expected error %v, got %v", failed, err)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "old" {
+		t.Fatalf("expected a failed write to keep %q, got %q", "old", content)
+	}
+
+	if err := write("new", nil); err != nil {
+		t.Fatal(err)
+	}
+	content, err = os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != "new" {
+		t.Fatalf("expected %q, got %q", "new", content)
+	}
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("expected only index.html, got %v", entries)
+	}
+}
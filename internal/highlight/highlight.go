@@ -0,0 +1,193 @@
+// Package highlight renders source code to HTML with a CSS class on every
+// token, and generates stylesheets that color those classes.
+package highlight
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Options change how code is rendered.
+type Options struct {
+	// LineNumbers numbers every line of the code.
+	LineNumbers bool
+	// Start is the number of the first line. When zero, lines are numbered
+	// from 1.
+	Start int
+	// Marked holds the lines to highlight, counted from 1 for the first line
+	// of the code regardless of Start.
+	Marked []int
+}
+
+// ParseOptions changes opts according to the options in the info string of a
+// fenced code block, the part after the language, such as
+// {lineNumbers start=10 highlight=1,3-5}. Options are separated by spaces:
+//
+//   - lineNumbers or lineNumbers=true|false turns line numbers on or off.
+//   - start=N numbers lines from N.
+//   - highlight=L highlights the lines L, a list of line numbers and ranges
+//     such as 1,3-5.
+//
+// An info string that does not start with '{' holds no options.
+func ParseOptions(info string, opts Options) (Options, error) {
+	info = strings.TrimSpace(info)
+	if !strings.HasPrefix(info, "{") {
+		return opts, nil
+	}
+	inner, ok := strings.CutSuffix(info[1:], "}")
+	if !ok {
+		return opts, errors.New("options must end with '}'")
+	}
+	for _, option := range strings.Fields(inner) {
+		name, value, hasValue := strings.Cut(option, "=")
+		var err error
+		switch {
+		case name == "lineNumbers" && !hasValue:
+			opts.LineNumbers = true
+		case name == "lineNumbers":
+			opts.LineNumbers, err = strconv.ParseBool(value)
+		case name == "start" && hasValue:
+			opts.Start, err = strconv.Atoi(value)
+			if err == nil && opts.Start < 1 {
+				err = errors.New("must be at least 1")
+			}
+		case name == "highlight" && hasValue:
+			opts.Marked, err = parseLines(value)
+		default:
+			return opts, fmt.Errorf("unknown option %q", option)
+		}
+		var numErr *strconv.NumError
+		if errors.As(err, &numErr) {
+			err = numErr.Err
+		}
+		if err != nil {
+			return opts, fmt.Errorf("invalid option %q: %w", option, err)
+		}
+	}
+	return opts, nil
+}
+
+// parseLines parses a list of line numbers and ranges such as 1,3-5.
+func parseLines(s string) ([]int, error) {
+	lines := []int{}
+	for part := range strings.SplitSeq(s, ",") {
+		from, to, isRange := strings.Cut(part, "-")
+		first, err := strconv.Atoi(from)
+		if err != nil {
+			return nil, err
+		}
+		last := first
+		if isRange {
+			if last, err = strconv.Atoi(to); err != nil {
+				return nil, err
+			}
+		}
+		if first < 1 || last < first {
+			return nil, fmt.Errorf("invalid line range %q", part)
+		}
+		for line := first; line <= last; line++ {
+			lines = append(lines, line)
+		}
+	}
+	return lines, nil
+}
+
+// WriteHTML writes code, written in lang, to w as the contents of a <code>
+// element. Tokens are wrapped in spans whose class is the Class of their
+// Kind. Code in languages that are not supported is escaped but not
+// highlighted.
+//
+// With line numbers or marked lines, every line is wrapped in a span of class
+// hl-line, with hl-mark for marked lines, that holds a span of class hl-ln
+// with the line's number and a span of class hl-code with the line's code.
+func WriteHTML(w io.Writer, lang string, code string, opts Options) error {
+	tokens := tokenize(lang, code)
+	lines := splitLines(tokens)
+	marked := make(map[int]bool, len(opts.Marked))
+	for _, line := range opts.Marked {
+		if line > len(lines) {
+			return fmt.Errorf("cannot highlight line %d of %d", line, len(lines))
+		}
+		marked[line] = true
+	}
+	start := max(opts.Start, 1)
+	wrap := opts.LineNumbers || len(marked) > 0
+	if !wrap {
+		lines = [][]Token{tokens}
+	}
+
+	sw := &stickyWriter{w: w}
+	for i, line := range lines {
+		if wrap {
+			class := "hl-line"
+			if marked[i+1] {
+				class += " hl-mark"
+			}
+			sw.printf(`<span class="%s">`, class)
+			if opts.LineNumbers {
+				sw.printf(`<span class="hl-ln">%d</span>`, start+i)
+			}
+			sw.printf(`<span class="hl-code">`)
+		}
+		for _, token := range line {
+			text := html.EscapeString(token.Text)
+			if class := token.Kind.Class(); class != "" {
+				sw.printf(`<span class="%s">%s</span>`, class, text)
+			} else {
+				sw.printf("%s", text)
+			}
+		}
+		if wrap {
+			sw.printf("</span></span>")
+		}
+	}
+	return sw.err
+}
+
+func tokenize(lang string, code string) []Token {
+	l := lookup(lang)
+	if l == nil {
+		return []Token{{Kind: Text, Text: code}}
+	}
+	return l.tokenize(code)
+}
+
+// splitLines splits tokens into lines, splitting tokens that span several
+// lines. Each line but the last one ends with the newline that ends it.
+func splitLines(tokens []Token) [][]Token {
+	lines := [][]Token{}
+	line := []Token{}
+	for _, token := range tokens {
+		for text := range strings.SplitAfterSeq(token.Text, "\n") {
+			if text == "" {
+				continue
+			}
+			line = append(line, Token{Kind: token.Kind, Text: text})
+			if strings.HasSuffix(text, "\n") {
+				lines = append(lines, line)
+				line = []Token{}
+			}
+		}
+	}
+	if len(line) > 0 {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// stickyWriter remembers the first error writing to w and ignores every write
+// after it.
+type stickyWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (sw *stickyWriter) printf(format string, args ...any) {
+	if sw.err == nil {
+		_, sw.err = fmt.Fprintf(sw.w, format, args...)
+	}
+}
@@ -0,0 +1,232 @@
+package highlight_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/fivethirty/satisficer/internal/highlight"
+)
+
+// span returns the HTML of a token of class.
+func span(class, text string) string {
+	return `<span class="hl-` + class + `">` + text + `</span>`
+}
+
+func TestWriteHTML(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		lang string
+		code string
+		opts highlight.Options
+		want string
+	}{
+		{
+			name: "escapes code in unknown languages",
+			lang: "unknown",
+			code: "if a < b {}\n",
+			want: "if a &lt; b {}\n",
+		},
+		{
+			name: "highlights go",
+			lang: "go",
+			code: "func f() error {\n\treturn nil // \"done\"\n}\n",
+			want: span("keyword", "func") + " " + span("function", "f") + "() " +
+				span("type", "error") + " {\n\t" + span("keyword", "return") + " " +
+				span("literal", "nil") + " " + span("comment", "// &#34;done&#34;") + "\n}\n",
+		},
+		{
+			name: "matches languages regardless of case",
+			lang: "Python",
+			code: "@cache\ndef f(): return '''a\nb'''\n",
+			want: span("meta", "@cache") + "\n" + span("keyword", "def") + " " +
+				span("function", "f") + "()" + span("operator", ":") + " " +
+				span("keyword", "return") + " " +
+				span("string", "&#39;&#39;&#39;a\nb&#39;&#39;&#39;") + "\n",
+		},
+		{
+			name: "highlights shell",
+			lang: "sh",
+			code: "echo \"$HOME\" $PATH # ~ is#not\n",
+			want: span("function", "echo") + " " + span("string", "&#34;$HOME&#34;") + " " +
+				span("variable", "$PATH") + " " + span("comment", "# ~ is#not") + "\n",
+		},
+		{
+			name: "highlights json keys",
+			lang: "json",
+			code: `{"a": [1, true]}`,
+			want: `{` + span("property", "&#34;a&#34;") + span("operator", ":") + " [" +
+				span("number", "1") + ", " + span("literal", "true") + "]}",
+		},
+		{
+			name: "highlights yaml keys",
+			lang: "yaml",
+			code: "url: https://example.com # c\nlist:\n  - \"a: b\"\n",
+			want: span("property", "url") + span("operator", ":") + " https://example.com " +
+				span("comment", "# c") + "\n" + span("property", "list") + span("operator", ":") +
+				"\n  " + span("operator", "- ") + span("string", "&#34;a: b&#34;") + "\n",
+		},
+		{
+			name: "highlights html tags",
+			lang: "html",
+			code: `<a href="/">A &amp; B</a>`,
+			want: span("tag", "&lt;a") + " " + span("attribute", "href") + span("operator", "=") +
+				span("string", "&#34;/&#34;") + span("tag", "&gt;") + "A " +
+				span("literal", "&amp;amp;") + " B" + span("tag", "&lt;/a&gt;"),
+		},
+		{
+			name: "highlights css",
+			lang: "css",
+			code: "p.a { color: #fff; }",
+			want: span("tag", "p") + span("attribute", ".a") + " { " + span("property", "color") +
+				span("operator", ":") + " " + span("number", "#fff") + "; }",
+		},
+		{
+			name: "highlights sql keywords regardless of case",
+			lang: "sql",
+			code: "select 1 FROM t -- c",
+			want: span("keyword", "select") + " " + span("number", "1") + " " +
+				span("keyword", "FROM") + " t " + span("comment", "-- c"),
+		},
+		{
+			name: "highlights diffs",
+			lang: "diff",
+			code: "@@ -1 +1 @@\n-a\n+b\n c\n",
+			want: span("meta", "@@ -1 +1 @@") + "\n" + span("deleted", "-a") + "\n" +
+				span("inserted", "+b") + "\n c\n",
+		},
+		{
+			name: "numbers lines and splits tokens across them",
+			lang: "go",
+			code: "/* a\nb */\n",
+			opts: highlight.Options{LineNumbers: true, Start: 9},
+			want: `<span class="hl-line"><span class="hl-ln">9</span><span class="hl-code">` +
+				span("comment", "/* a\n") + `</span></span>` +
+				`<span class="hl-line"><span class="hl-ln">10</span><span class="hl-code">` +
+				span("comment", "b */") + "\n</span></span>",
+		},
+		{
+			name: "marks lines",
+			code: "a\nb\n",
+			opts: highlight.Options{Marked: []int{2}},
+			want: `<span class="hl-line"><span class="hl-code">a` + "\n</span></span>" +
+				`<span class="hl-line hl-mark"><span class="hl-code">b` + "\n</span></span>",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			b := &strings.Builder{}
+			if err := highlight.WriteHTML(b, test.lang, test.code, test.opts); err != nil {
+				t.Fatal(err)
+			}
+			if b.String() != test.want {
+				t.Fatalf("expected\n%s\ngot\n%s", test.want, b.String())
+			}
+		})
+	}
+}
+
+func TestWriteHTML_MarkedLineOutOfRange(t *testing.T) {
+	t.Parallel()
+
+	err := highlight.WriteHTML(&strings.Builder{}, "", "a\n", highlight.Options{Marked: []int{2}})
+	if want := "cannot highlight line 2 of 1"; err == nil || err.Error() != want {
+		t.Fatalf("expected error %q, got %v", want, err)
+	}
+}
+
+func TestParseOptions(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		info      string
+		defaults  highlight.Options
+		want      highlight.Options
+		wantError string
+	}{
+		{
+			name:     "keeps the defaults without options",
+			info:     ` title="main.go"`,
+			defaults: highlight.Options{LineNumbers: true},
+			want:     highlight.Options{LineNumbers: true},
+		},
+		{
+			name: "parses every option",
+			info: " {lineNumbers start=10 highlight=1,3-5}",
+			want: highlight.Options{LineNumbers: true, Start: 10, Marked: []int{1, 3, 4, 5}},
+		},
+		{
+			name:     "turns line numbers off",
+			info:     "{lineNumbers=false}",
+			defaults: highlight.Options{LineNumbers: true},
+			want:     highlight.Options{},
+		},
+		{
+			name:      "reports unknown options",
+			info:      "{lines}",
+			wantError: `unknown option "lines"`,
+		},
+		{
+			name:      "reports invalid numbers",
+			info:      "{start=ten}",
+			wantError: `invalid option "start=ten": invalid syntax`,
+		},
+		{
+			name:      "reports invalid ranges",
+			info:      "{highlight=5-3}",
+			wantError: `invalid option "highlight=5-3": invalid line range "5-3"`,
+		},
+		{
+			name:      "reports unterminated options",
+			info:      "{lineNumbers",
+			wantError: "options must end with '}'",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			opts, err := highlight.ParseOptions(test.info, test.defaults)
+			if test.wantError != "" {
+				if err == nil || err.Error() != test.wantError {
+					t.Fatalf("expected error %q, got %v", test.wantError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(opts, test.want) {
+				t.Fatalf("expected %+v, got %+v", test.want, opts)
+			}
+		})
+	}
+}
+
+func TestWriteStylesheet(t *testing.T) {
+	t.Parallel()
+
+	for _, theme := range highlight.Themes() {
+		b := &strings.Builder{}
+		if err := highlight.WriteStylesheet(b, theme); err != nil {
+			t.Fatal(err)
+		}
+		for _, selector := range []string{".highlight {", ".hl-mark {", ".hl-keyword {"} {
+			if !strings.Contains(b.String(), selector) {
+				t.Fatalf("%s: expected %q in\n%s", theme, selector, b.String())
+			}
+		}
+	}
+
+	err := highlight.WriteStylesheet(&strings.Builder{}, "unknown")
+	want := `unknown theme "unknown", expected one of github, github-dark, monokai, ` +
+		"solarized-light"
+	if err == nil || err.Error() != want {
+		t.Fatalf("expected error %q, got %v", want, err)
+	}
+}
@@ -0,0 +1,350 @@
+package highlight
+
+import "strings"
+
+// Patterns shared by several languages.
+const (
+	identifier   = `[\p{L}_][\p{L}\p{N}_]*`
+	whitespace   = `\s+`
+	slashComment = `//[^\n]*`
+	blockComment = `/\*(?s:.*?)(?:\*/|\z)`
+	hashComment  = `#[^\n]*`
+	// Strings are allowed to be unterminated, so that a missing quote
+	// only affects the rest of its line.
+	doubleQuoted = `"(?:[^"\\\n]|\\.)*"?`
+	singleQuoted = `'(?:[^'\\\n]|\\.)*'?`
+	// Keys must be terminated strings.
+	doubleQuotedKey = `"(?:[^"\\\n]|\\.)*"`
+	singleQuotedKey = `'(?:[^'\n]|'')*'`
+	backtickQuoted  = "`[^`]*`?"
+	number          = `(?i:0x[0-9a-f_]+|0o[0-7_]+|0b[01_]+|` +
+		`(?:\d[\d_]*(?:\.\d[\d_]*)?|\.\d[\d_]*)(?:e[+-]?\d[\d_]*)?)[a-z]*`
+	operator = `[-+*/%=&|^!<>~?:]+`
+)
+
+// cLike returns the rules of a language with C-like comments, strings and
+// operators. before are tried first, for syntax that would otherwise match
+// one of the common rules.
+func cLike(before ...rule) map[string][]rule {
+	return map[string][]rule{
+		rootState: append(
+			before,
+			match(Text, whitespace),
+			match(Comment, slashComment),
+			match(Comment, blockComment),
+			match(String, doubleQuoted),
+			match(String, singleQuoted),
+			match(Number, number),
+			word(identifier),
+			match(Operator, operator),
+		),
+	}
+}
+
+var golang = &language{
+	states: cLike(match(String, backtickQuoted)),
+	keywords: words(`break case chan const continue default defer else
+		fallthrough for func go goto if import interface map package range
+		return select struct switch type var`),
+	literals: words(`true false nil iota`),
+	types: words(`any bool byte comparable complex64 complex128 error float32
+		float64 int int8 int16 int32 int64 rune string uint uint8 uint16 uint32
+		uint64 uintptr`),
+}
+
+var javaScriptKeywords = `async await break case catch class const continue
+	debugger default delete do else export extends finally for from function
+	get if import in instanceof let new of return set static super switch this
+	throw try typeof var void while with yield`
+
+var javaScript = &language{
+	states: cLike(
+		match(String, "`(?:[^`\\\\]|\\\\.)*`?"),
+		word(`[\p{L}_$][\p{L}\p{N}_$]*`),
+	),
+	keywords: words(javaScriptKeywords),
+	literals: words(`true false null undefined NaN Infinity`),
+	types: words(`Array Boolean Date Error Map Number Object Promise RegExp
+		Set String Symbol`),
+}
+
+var typeScript = &language{
+	states: javaScript.states,
+	keywords: words(javaScriptKeywords + ` abstract as declare enum implements
+		interface keyof namespace private protected public readonly satisfies
+		type`),
+	literals: javaScript.literals,
+	types: words(`any bigint boolean never number object string symbol unknown
+		void Array Date Error Map Promise Record Set`),
+}
+
+var python = &language{
+	states: map[string][]rule{
+		rootState: {
+			match(Text, whitespace),
+			match(Comment, hashComment),
+			match(String, `(?i:[rbuf]{0,2})(?:"""(?s:.*?)(?:"""|\z)|'''(?s:.*?)(?:'''|\z))`),
+			match(String, `(?i:[rbuf]{0,2})(?:`+doubleQuoted+`|`+singleQuoted+`)`),
+			match(Meta, `@[\p{L}_][\p{L}\p{N}_.]*`),
+			match(Number, number),
+			word(identifier),
+			match(Operator, operator),
+		},
+	},
+	keywords: words(`and as assert async await break class continue def del
+		elif else except finally for from global if import in is lambda match
+		nonlocal not or pass raise return try while with yield`),
+	literals: words(`True False None`),
+	types: words(`bool bytes dict float frozenset int list object set str
+		tuple type`),
+}
+
+var rust = &language{
+	states: cLike(
+		match(String, `b?'(?:[^'\\\n]|\\[^\n]+?)'`),
+		match(Meta, `'[\p{L}_][\p{L}\p{N}_]*`),
+		match(Meta, `#!?\[[^\]\n]*\]`),
+		groups(`([\p{L}_][\p{L}\p{N}_]*!)[ \t]*[(\[{]`, Function),
+	),
+	keywords: words(`as async await break const continue crate dyn else enum
+		extern fn for if impl in let loop match mod move mut pub ref return
+		self static struct super trait type unsafe use where while`),
+	literals: words(`true false`),
+	types: words(`bool char f32 f64 i8 i16 i32 i64 i128 isize str u8 u16 u32
+		u64 u128 usize Box Option Result Self String Vec Some None Ok Err`),
+}
+
+var cKeywords = `break case const continue default do else enum extern for
+	goto if inline register restrict return sizeof static struct switch
+	typedef union volatile while`
+
+var cTypes = `bool char double float int long short signed unsigned void
+	size_t int8_t int16_t int32_t int64_t uint8_t uint16_t uint32_t uint64_t`
+
+var c = &language{
+	states:   cLike(match(Meta, `#[ \t]*[a-z]+[^\n]*`).atLineStart()),
+	keywords: words(cKeywords),
+	literals: words(`true false NULL`),
+	types:    words(cTypes),
+}
+
+var cpp = &language{
+	states: c.states,
+	keywords: words(cKeywords + ` auto catch class constexpr delete explicit
+		friend mutable namespace new noexcept operator override private
+		protected public template this throw try typename using virtual`),
+	literals: words(`true false nullptr NULL`),
+	types:    words(cTypes + ` string vector map`),
+}
+
+var java = &language{
+	states: cLike(match(Meta, `@[\p{L}_][\p{L}\p{N}_.]*`)),
+	keywords: words(`abstract assert break case catch class continue default
+		do else enum extends final finally for if implements import instanceof
+		interface native new package private protected public record return
+		static super switch synchronized this throw throws try var void
+		volatile while yield`),
+	literals: words(`true false null`),
+	types: words(`boolean byte char double float int long short Integer Long
+		Object String`),
+}
+
+var shell = &language{
+	states: map[string][]rule{
+		rootState: {
+			match(Text, whitespace),
+			// # only starts a comment at the start of a word.
+			match(Comment, hashComment).atWordStart(),
+			match(Variable, `\$(?:\{[^}\n]*\}?|[\p{L}_][\p{L}\p{N}_]*|[@*#?$!0-9-])`),
+			match(String, doubleQuoted),
+			match(String, `'[^']*'?`),
+			match(Number, `\d+\b`),
+			word(`[\p{L}_][\p{L}\p{N}_-]*`),
+			match(Operator, `&&|\|\||[|&;<>]+`),
+		},
+	},
+	keywords: words(`case do done elif else esac fi for function if in local
+		return select then until while export readonly declare unset shift
+		break continue exit`),
+	builtins: words(`echo printf cd read source eval exec set test alias cat
+		ls grep sed awk mkdir rm cp mv curl git go`),
+}
+
+var jsonLanguage = &language{
+	states: map[string][]rule{
+		rootState: {
+			match(Text, whitespace),
+			groups(`(`+doubleQuotedKey+`)\s*(:)`, Property, Operator),
+			match(String, doubleQuoted),
+			match(Number, `-?`+number),
+			word(identifier),
+		},
+	},
+	literals: words(`true false null`),
+}
+
+var yaml = &language{
+	states: map[string][]rule{
+		rootState: {
+			match(Text, whitespace),
+			match(Comment, hashComment).atWordStart(),
+			match(Meta, `(?:---|\.\.\.)[ \t]*(?:\n|\z)`).atLineStart(),
+			groups(`(`+doubleQuotedKey+`|`+singleQuotedKey+`|[^\s#'"{}\[\],:-][^\n#:]*?)`+
+				`[ \t]*(:)(?:[ \t]|\n|\z)`, Property, Operator),
+			match(Operator, `-(?:[ \t]|\n|\z)`),
+			match(String, doubleQuoted),
+			match(String, `'(?:[^'\n]|'')*'?`),
+			match(Meta, `[&*][^\s,\[\]{}]+`),
+			match(Operator, `[|>][-+]?`),
+			match(Number, `[-+]?`+number+`\b`),
+			word(identifier),
+		},
+	},
+	literals: words(`true false null True False Null TRUE FALSE NULL yes no`),
+}
+
+var toml = &language{
+	states: map[string][]rule{
+		rootState: {
+			match(Text, whitespace),
+			match(Comment, hashComment),
+			match(Type, `\[\[?[^\]\n]*\]\]?`).atLineStart(),
+			groups(`([\p{L}\p{N}_-]+(?:[ \t]*\.[ \t]*[\p{L}\p{N}_-]+)*)[ \t]*(=)`,
+				Property, Operator),
+			match(String, `"""(?s:.*?)(?:"""|\z)|'''(?s:.*?)(?:'''|\z)`),
+			match(String, doubleQuoted),
+			match(String, `'[^'\n]*'?`),
+			match(Number, `\d{4}-\d{2}-\d{2}(?:[T ]\d{2}:\d{2}(?::\d{2}(?:\.\d+)?)?`+
+				`(?:Z|[+-]\d{2}:\d{2})?)?|\d{2}:\d{2}(?::\d{2}(?:\.\d+)?)?`),
+			match(Number, `[-+]?`+number),
+			word(identifier),
+		},
+	},
+	literals: words(`true false inf nan`),
+}
+
+var markup = &language{
+	states: map[string][]rule{
+		rootState: {
+			match(Comment, `<!--(?s:.*?)(?:-->|\z)`),
+			match(Meta, `<![^>]*>?|<\?(?s:.*?)(?:\?>|\z)`),
+			groups(`(</?)([\p{L}_][\p{L}\p{N}_:.-]*)`, Tag, Tag).push("tag"),
+			match(Literal, `&(?:#[0-9]+|#x[0-9a-fA-F]+|[a-zA-Z]+);`),
+			match(Text, `[^<&]+`),
+		},
+		"tag": {
+			match(Text, whitespace),
+			match(Tag, `/?>`).pop(),
+			match(Attribute, `[^\s"'>/=]+`),
+			match(Operator, `=`),
+			match(String, `"[^"]*"?|'[^']*'?`),
+		},
+	},
+}
+
+var css = &language{
+	states: map[string][]rule{
+		rootState: {
+			match(Text, whitespace),
+			match(Comment, blockComment),
+			match(Keyword, `@[\w-]+`),
+			match(Text, `\{`).push("block"),
+			match(Attribute, `[.#][\w-]+`),
+			match(Meta, `::?[\w-]+`),
+			match(String, doubleQuoted),
+			match(String, singleQuoted),
+			match(Tag, `[\w-]+`),
+		},
+		"block": {
+			match(Text, whitespace),
+			match(Comment, blockComment),
+			match(Text, `\{`).push("block"),
+			match(Text, `\}`).pop(),
+			groups(`(--[\w-]+|-?[a-zA-Z][\w-]*)[ \t]*(:)`, Property, Operator),
+			match(Keyword, `!important`),
+			match(Number, `#[0-9a-fA-F]{3,8}\b`),
+			match(Number, `[-+]?(?:\d+\.?\d*|\.\d+)(?:[a-zA-Z]+|%)?`),
+			match(String, doubleQuoted),
+			match(String, singleQuoted),
+			word(`-?[a-zA-Z_][\w-]*`),
+		},
+	},
+}
+
+var sql = &language{
+	states: map[string][]rule{
+		rootState: {
+			match(Text, whitespace),
+			match(Comment, `--[^\n]*`),
+			match(Comment, blockComment),
+			match(String, `'(?:[^']|'')*'?`),
+			match(Text, `"[^"]*"?`),
+			match(Number, number),
+			word(identifier),
+			match(Operator, `[-+*/%=<>!|]+`),
+		},
+	},
+	keywords: words(`add all alter and as asc begin between by case check
+		column commit constraint create cross default delete desc distinct drop
+		else end exists foreign from full group having if in index inner insert
+		into is join key left like limit not offset on or order outer primary
+		references returning right rollback select set table then transaction
+		union unique update using values view when where with`),
+	literals: words(`true false null`),
+	types: words(`bigint blob boolean char date decimal double float int
+		integer numeric real serial text time timestamp varchar`),
+	ignoreCase: true,
+}
+
+var diff = &language{
+	states: map[string][]rule{
+		rootState: {
+			match(Meta, `(?:diff|index|---|\+\+\+|@@)[^\n]*`).atLineStart(),
+			match(Inserted, `[+>][^\n]*`).atLineStart(),
+			match(Deleted, `[-<][^\n]*`).atLineStart(),
+			match(Text, `[^\n]*\n?`),
+		},
+	},
+}
+
+// languages holds every supported language by the names that can be used in
+// the info string of fenced code blocks.
+var languages = map[string]*language{
+	"go":         golang,
+	"golang":     golang,
+	"javascript": javaScript,
+	"js":         javaScript,
+	"jsx":        javaScript,
+	"mjs":        javaScript,
+	"typescript": typeScript,
+	"ts":         typeScript,
+	"tsx":        typeScript,
+	"python":     python,
+	"py":         python,
+	"rust":       rust,
+	"rs":         rust,
+	"c":          c,
+	"h":          c,
+	"cpp":        cpp,
+	"c++":        cpp,
+	"java":       java,
+	"bash":       shell,
+	"sh":         shell,
+	"shell":      shell,
+	"zsh":        shell,
+	"json":       jsonLanguage,
+	"yaml":       yaml,
+	"yml":        yaml,
+	"toml":       toml,
+	"html":       markup,
+	"xml":        markup,
+	"svg":        markup,
+	"css":        css,
+	"sql":        sql,
+	"diff":       diff,
+	"patch":      diff,
+}
+
+func lookup(name string) *language {
+	return languages[strings.ToLower(name)]
+}
@@ -0,0 +1,259 @@
+package highlight
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Kind is the kind of a token, which decides its CSS class.
+type Kind int
+
+const (
+	Text Kind = iota
+	Comment
+	Keyword
+	String
+	Number
+	// Literal is a named constant such as true or nil.
+	Literal
+	Type
+	// Function is a name that is called or defined.
+	Function
+	Operator
+	Tag
+	Attribute
+	// Property is a key in a mapping, such as a JSON object or CSS rule.
+	Property
+	Variable
+	// Meta is anything that is about the code rather than code, such as
+	// preprocessor directives, annotations and diff headers.
+	Meta
+	Inserted
+	Deleted
+)
+
+var classes = [...]string{
+	Text:      "",
+	Comment:   "hl-comment",
+	Keyword:   "hl-keyword",
+	String:    "hl-string",
+	Number:    "hl-number",
+	Literal:   "hl-literal",
+	Type:      "hl-type",
+	Function:  "hl-function",
+	Operator:  "hl-operator",
+	Tag:       "hl-tag",
+	Attribute: "hl-attribute",
+	Property:  "hl-property",
+	Variable:  "hl-variable",
+	Meta:      "hl-meta",
+	Inserted:  "hl-inserted",
+	Deleted:   "hl-deleted",
+}
+
+// Class returns the CSS class of tokens of kind k, or "" for Text.
+func (k Kind) Class() string {
+	return classes[k]
+}
+
+type Token struct {
+	Kind Kind
+	Text string
+}
+
+// rule matches a token at the current position of the source. Rules of a
+// state are tried in order and the first one that matches wins.
+type rule struct {
+	pattern *regexp.Regexp
+	kind    Kind
+	// groups, if set, holds the kind of each capture group of pattern, so
+	// that a single match can hold tokens of several kinds. Text that is not
+	// in a group is Text.
+	groups []Kind
+	// word classifies the match as a keyword, literal, type, function or
+	// text using the words of the language.
+	word bool
+	// lineStart only lets the rule match when nothing but spaces precede
+	// it on its line, and wordStart when a space or nothing precedes it.
+	lineStart bool
+	wordStart bool
+	// next is the state to push after the rule matched, or popState to go
+	// back to the previous state.
+	next string
+}
+
+const (
+	rootState = "root"
+	popState  = "#pop"
+)
+
+type language struct {
+	states   map[string][]rule
+	keywords map[string]bool
+	literals map[string]bool
+	types    map[string]bool
+	// builtins are names of functions or commands that are called without
+	// parentheses.
+	builtins   map[string]bool
+	ignoreCase bool
+}
+
+// match returns a rule for kind that matches pattern.
+func match(kind Kind, pattern string) rule {
+	return rule{pattern: compile(pattern), kind: kind}
+}
+
+// groups returns a rule that matches pattern, whose capture groups are
+// tokens of kinds.
+func groups(pattern string, kinds ...Kind) rule {
+	return rule{pattern: compile(pattern), groups: kinds}
+}
+
+// word returns a rule that classifies words matched by pattern.
+func word(pattern string) rule {
+	return rule{pattern: compile(pattern), word: true}
+}
+
+func (r rule) atLineStart() rule {
+	r.lineStart = true
+	return r
+}
+
+func (r rule) atWordStart() rule {
+	r.wordStart = true
+	return r
+}
+
+func (r rule) push(state string) rule {
+	r.next = state
+	return r
+}
+
+func (r rule) pop() rule {
+	r.next = popState
+	return r
+}
+
+func compile(pattern string) *regexp.Regexp {
+	return regexp.MustCompile(`\A(?:` + pattern + `)`)
+}
+
+func words(s string) map[string]bool {
+	set := map[string]bool{}
+	for _, w := range strings.Fields(s) {
+		set[w] = true
+	}
+	return set
+}
+
+// tokenize splits src into tokens. Text that no rule matches is Text, so the
+// tokens always add up to src.
+func (l *language) tokenize(src string) []Token {
+	t := &tokenizer{src: src}
+	stack := []string{rootState}
+	for t.pos < len(src) {
+		next, ok := l.next(t, stack[len(stack)-1])
+		if !ok {
+			_, n := utf8.DecodeRuneInString(src[t.pos:])
+			t.add(Text, n)
+		}
+		switch next {
+		case "":
+		case popState:
+			if len(stack) > 1 {
+				stack = stack[:len(stack)-1]
+			}
+		default:
+			stack = append(stack, next)
+		}
+	}
+	return t.tokens
+}
+
+// next adds the tokens of the first rule of state that matches at the
+// position of t, and returns the state to go to. It returns false if no rule
+// matches.
+func (l *language) next(t *tokenizer, state string) (string, bool) {
+	rest := t.src[t.pos:]
+	for _, r := range l.states[state] {
+		if r.lineStart && !atLineStart(t.src, t.pos) ||
+			r.wordStart && t.pos > 0 && !unicode.IsSpace(rune(t.src[t.pos-1])) {
+			continue
+		}
+		loc := r.pattern.FindStringSubmatchIndex(rest)
+		if loc == nil || loc[1] == 0 {
+			continue
+		}
+		switch {
+		case r.word:
+			t.add(l.classify(rest[:loc[1]], rest[loc[1]:]), loc[1])
+		case r.groups != nil:
+			end := 0
+			for i, kind := range r.groups {
+				start, stop := loc[2*i+2], loc[2*i+3]
+				if start < 0 {
+					continue
+				}
+				t.add(Text, start-end)
+				t.add(kind, stop-start)
+				end = stop
+			}
+			t.add(Text, loc[1]-end)
+		default:
+			t.add(r.kind, loc[1])
+		}
+		return r.next, true
+	}
+	return "", false
+}
+
+func (l *language) classify(w string, after string) Kind {
+	key := w
+	if l.ignoreCase {
+		key = strings.ToLower(w)
+	}
+	switch {
+	case l.keywords[key]:
+		return Keyword
+	case l.literals[key]:
+		return Literal
+	case l.types[key]:
+		return Type
+	case l.builtins[key]:
+		return Function
+	case strings.HasPrefix(strings.TrimLeft(after, " \t"), "("):
+		return Function
+	default:
+		return Text
+	}
+}
+
+func atLineStart(src string, pos int) bool {
+	line := src[strings.LastIndexByte(src[:pos], '\n')+1 : pos]
+	return strings.Trim(line, " \t") == ""
+}
+
+// tokenizer splits src into tokens from start to end, merging adjacent
+// tokens of the same kind.
+type tokenizer struct {
+	src    string
+	pos    int
+	tokens []Token
+}
+
+// add adds the next n bytes of src as a token of kind.
+func (t *tokenizer) add(kind Kind, n int) {
+	if n == 0 {
+		return
+	}
+	start := t.pos
+	t.pos += n
+	if last := len(t.tokens) - 1; last >= 0 && t.tokens[last].Kind == kind {
+		start -= len(t.tokens[last].Text)
+		t.tokens[last].Text = t.src[start:t.pos]
+		return
+	}
+	t.tokens = append(t.tokens, Token{Kind: kind, Text: t.src[start:t.pos]})
+}
@@ -0,0 +1,154 @@
+package highlight
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// DefaultTheme is the theme of stylesheets when none is chosen.
+const DefaultTheme = "github"
+
+// theme holds the CSS declarations of highlighted code.
+type theme struct {
+	// code styles the code as a whole.
+	code string
+	// lineNumber and mark style line numbers and marked lines.
+	lineNumber string
+	mark       string
+	// tokens holds the styles of tokens by kind. Kinds without a style look
+	// like Text.
+	tokens map[Kind]string
+}
+
+var themes = map[string]theme{
+	"github": {
+		code:       "color: #24292f; background-color: #f6f8fa",
+		lineNumber: "color: #8c959f",
+		mark:       "background-color: #fff8c5",
+		tokens: map[Kind]string{
+			Comment:   "color: #6e7781; font-style: italic",
+			Keyword:   "color: #cf222e",
+			String:    "color: #0a3069",
+			Number:    "color: #0550ae",
+			Literal:   "color: #0550ae",
+			Type:      "color: #953800",
+			Function:  "color: #8250df",
+			Operator:  "color: #cf222e",
+			Tag:       "color: #116329",
+			Attribute: "color: #0550ae",
+			Property:  "color: #0550ae",
+			Variable:  "color: #953800",
+			Meta:      "color: #6639ba",
+			Inserted:  "color: #116329; background-color: #dafbe1",
+			Deleted:   "color: #82071e; background-color: #ffebe9",
+		},
+	},
+	"github-dark": {
+		code:       "color: #c9d1d9; background-color: #161b22",
+		lineNumber: "color: #6e7681",
+		mark:       "background-color: #3b3220",
+		tokens: map[Kind]string{
+			Comment:   "color: #8b949e; font-style: italic",
+			Keyword:   "color: #ff7b72",
+			String:    "color: #a5d6ff",
+			Number:    "color: #79c0ff",
+			Literal:   "color: #79c0ff",
+			Type:      "color: #ffa657",
+			Function:  "color: #d2a8ff",
+			Operator:  "color: #ff7b72",
+			Tag:       "color: #7ee787",
+			Attribute: "color: #79c0ff",
+			Property:  "color: #79c0ff",
+			Variable:  "color: #ffa657",
+			Meta:      "color: #d2a8ff",
+			Inserted:  "color: #aff5b4; background-color: #033a16",
+			Deleted:   "color: #ffdcd7; background-color: #67060c",
+		},
+	},
+	"monokai": {
+		code:       "color: #f8f8f2; background-color: #272822",
+		lineNumber: "color: #90908a",
+		mark:       "background-color: #3e3d32",
+		tokens: map[Kind]string{
+			Comment:   "color: #75715e; font-style: italic",
+			Keyword:   "color: #f92672",
+			String:    "color: #e6db74",
+			Number:    "color: #ae81ff",
+			Literal:   "color: #ae81ff",
+			Type:      "color: #66d9ef; font-style: italic",
+			Function:  "color: #a6e22e",
+			Operator:  "color: #f92672",
+			Tag:       "color: #f92672",
+			Attribute: "color: #a6e22e",
+			Property:  "color: #66d9ef",
+			Variable:  "color: #fd971f",
+			Meta:      "color: #fd971f",
+			Inserted:  "color: #a6e22e",
+			Deleted:   "color: #f92672",
+		},
+	},
+	"solarized-light": {
+		code:       "color: #657b83; background-color: #fdf6e3",
+		lineNumber: "color: #93a1a1",
+		mark:       "background-color: #eee8d5",
+		tokens: map[Kind]string{
+			Comment:   "color: #93a1a1; font-style: italic",
+			Keyword:   "color: #859900",
+			String:    "color: #2aa198",
+			Number:    "color: #d33682",
+			Literal:   "color: #cb4b16",
+			Type:      "color: #b58900",
+			Function:  "color: #268bd2",
+			Operator:  "color: #859900",
+			Tag:       "color: #268bd2",
+			Attribute: "color: #b58900",
+			Property:  "color: #268bd2",
+			Variable:  "color: #cb4b16",
+			Meta:      "color: #6c71c4",
+			Inserted:  "color: #859900",
+			Deleted:   "color: #dc322f",
+		},
+	},
+}
+
+// Themes returns the names of every theme, sorted.
+func Themes() []string {
+	names := make([]string, 0, len(themes))
+	for name := range themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// WriteStylesheet writes a stylesheet that colors code rendered by WriteHTML
+// within a <pre class="highlight"> element using the theme name.
+func WriteStylesheet(w io.Writer, name string) error {
+	t, ok := themes[name]
+	if !ok {
+		return fmt.Errorf(
+			"unknown theme %q, expected one of %s",
+			name,
+			strings.Join(Themes(), ", "),
+		)
+	}
+
+	sw := &stickyWriter{w: w}
+	sw.printf("/* Generated by Satisficer with the %s theme. */\n", name)
+	rule := func(selector, declarations string) {
+		sw.printf(".highlight%s { %s; }\n", selector, declarations)
+	}
+	rule("", t.code)
+	rule(" .hl-line", "display: flex")
+	rule(" .hl-mark", t.mark)
+	rule(" .hl-ln", t.lineNumber+"; min-width: 2em; padding-right: 1em; "+
+		"text-align: right; user-select: none")
+	for kind := range classes {
+		if style, ok := t.tokens[Kind(kind)]; ok {
+			rule(" ."+Kind(kind).Class(), style)
+		}
+	}
+	return sw.err
+}
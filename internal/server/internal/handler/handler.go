@@ -12,6 +12,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/fivethirty/satisficer/internal/server/internal/handler/hub"
 	"github.com/fivethirty/satisficer/internal/server/internal/handler/responsebody"
 )
 
@@ -24,7 +25,6 @@ type Builder interface {
 }
 
 type build struct {
-	dir        string
 	fileServer http.Handler
 	err        error
 }
@@ -32,19 +32,23 @@ type build struct {
 type Handler struct {
 	watcher Watcher
 	builder Builder
-	baseDir string
+	dir     string
 	build   atomic.Value
-	buildCh chan time.Time
+	hub     *hub.Hub
 	mux     *http.ServeMux
 	ctx     context.Context
 }
 
 func Start(ctx context.Context, w Watcher, b Builder, baseDir string) (*Handler, error) {
+	dir, err := os.MkdirTemp(baseDir, "satisficer-server-build-")
+	if err != nil {
+		return nil, err
+	}
 	h := Handler{
 		watcher: w,
 		builder: b,
-		buildCh: make(chan time.Time, 1),
-		baseDir: baseDir,
+		hub:     hub.New(),
+		dir:     dir,
 		ctx:     ctx,
 	}
 
@@ -86,9 +90,13 @@ func (h *Handler) events(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
 		return
 	}
+	ch, unsubscribe := h.hub.Subscribe()
+	defer unsubscribe()
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
 	for {
 		select {
-		case <-h.buildCh:
+		case <-ch:
 			_, err := fmt.Fprint(w, "data: rebuild\n\n")
 			if err != nil {
 				http.Error(w, "failed to write event", http.StatusInternalServerError)
@@ -175,43 +183,29 @@ func (h *Handler) files(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// Subscribers returns the number of clients connected to the event stream.
+func (h *Handler) Subscribers() int {
+	return h.hub.Len()
+}
+
 func (h *Handler) publish() {
-	select {
-	case h.buildCh <- time.Now():
-	default:
-	}
+	h.hub.Publish(time.Now())
 }
 
+// rebuild builds into the same directory every time so that the builder
+// can skip outputs whose inputs have not changed since the last build. Files
+// are served while it runs: the builder replaces each output in one rename,
+// so a request sees either the old or the new file.
 func (h *Handler) rebuild() {
-	oldBuild, ok := h.build.Load().(build)
-	if ok {
-		oldDir := oldBuild.dir
-		defer func() {
-			if oldDir == "" {
-				return
-			}
-			if err := os.RemoveAll(oldDir); err != nil {
-				slog.Warn("failed to remove build directory", "path", oldDir, "error", err)
-			}
-		}()
-	}
-
-	dir, err := os.MkdirTemp(h.baseDir, "satisficer-server-build-")
-	if err != nil {
-		h.build.Store(build{err: err})
-		return
-	}
-
-	buildErr := h.builder.Build(dir)
+	buildErr := h.builder.Build(h.dir)
 	if buildErr != nil {
 		slog.Error("build failed", "error", buildErr)
 	}
 
 	h.build.Store(
 		build{
-			dir:        dir,
 			err:        buildErr,
-			fileServer: http.FileServer(http.Dir(dir)),
+			fileServer: http.FileServer(http.Dir(h.dir)),
 		},
 	)
 }
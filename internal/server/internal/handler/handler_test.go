@@ -2,7 +2,9 @@ package handler_test
 
 import (
 	"bufio"
+	"context"
 	_ "embed"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -214,12 +216,12 @@ func testRequest(t *testing.T, server *httptest.Server, fb *fakeBuilder) {
 
 func sseCh(t *testing.T, server *httptest.Server) <-chan error {
 	t.Helper()
+	resp, err := http.Get(server.URL + "/_/events")
+	if err != nil {
+		t.Fatal(err)
+	}
 	ch := make(chan error, 1)
 	go func() {
-		resp, err := http.Get(server.URL + "/_/events")
-		if err != nil {
-			return
-		}
 		defer func() {
 			_ = resp.Body.Close()
 		}()
@@ -232,12 +234,11 @@ func sseCh(t *testing.T, server *httptest.Server) <-chan error {
 			default:
 				line, err := reader.ReadString('\n')
 				if err != nil {
-					if err.Error() == "EOF" {
-						break
-					} else {
-						ch <- fmt.Errorf("error reading event stream: %v", err)
+					if errors.Is(err, io.EOF) {
 						return
 					}
+					ch <- fmt.Errorf("error reading event stream: %v", err)
+					return
 				}
 				if line == "\n" {
 					continue
@@ -253,7 +254,119 @@ func sseCh(t *testing.T, server *httptest.Server) <-chan error {
 	return ch
 }
 
-func TestHandler_RemovesTempFilesOnRebuild(t *testing.T) {
+func TestHandler_BroadcastsToAllClients(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		clients int
+	}{
+		{
+			name:    "one client",
+			clients: 1,
+		},
+		{
+			name:    "two clients",
+			clients: 2,
+		},
+		{
+			name:    "many clients",
+			clients: 10,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			watcherCh := make(chan time.Time)
+			w := newFakeWatcher(watcherCh)
+			fb := &fakeBuilder{
+				content: "initial build",
+			}
+
+			h, err := handler.Start(t.Context(), w, fb, t.TempDir())
+			if err != nil {
+				t.Fatal(err)
+			}
+			server := httptest.NewServer(h)
+			t.Cleanup(server.Close)
+
+			chs := make([]<-chan error, 0, test.clients)
+			for range test.clients {
+				chs = append(chs, sseCh(t, server))
+			}
+
+			for range 2 {
+				watcherCh <- time.Now()
+				for i, ch := range chs {
+					select {
+					case err := <-ch:
+						if err != nil {
+							t.Fatalf("error from SSE client %d: %v", i, err)
+						}
+					case <-time.After(100 * time.Millisecond):
+						t.Fatalf("timeout waiting for rebuild event on client %d", i)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestHandler_ClientDisconnect(t *testing.T) {
+	t.Parallel()
+
+	watcherCh := make(chan time.Time)
+	w := newFakeWatcher(watcherCh)
+	fb := &fakeBuilder{
+		content: "initial build",
+	}
+
+	h, err := handler.Start(t.Context(), w, fb, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(h)
+	t.Cleanup(server.Close)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/_/events", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.Subscribers() != 1 {
+		t.Fatalf("expected 1 subscriber, got %d", h.Subscribers())
+	}
+	cancel()
+	_ = resp.Body.Close()
+
+	deadline := time.Now().Add(time.Second)
+	for h.Subscribers() != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the client to be removed, got %d subscribers", h.Subscribers())
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	sCh := sseCh(t, server)
+	watcherCh <- time.Now()
+
+	select {
+	case err := <-sCh:
+		if err != nil {
+			t.Fatalf("error from SSE client: %v", err)
+		}
+	case <-time.After(100 * time.Millisecond):
+		t.Fatalf("timeout waiting for rebuild event")
+	}
+}
+
+func TestHandler_ReusesBuildDirOnRebuild(t *testing.T) {
 	t.Parallel()
 
 	watcherCh := make(chan time.Time)
@@ -291,3 +404,58 @@ func TestHandler_RemovesTempFilesOnRebuild(t *testing.T) {
 		t.Fatalf("expected one file after build, found %d", len(files))
 	}
 }
+
+// blockingBuilder waits for release before every build after the first.
+type blockingBuilder struct {
+	fakeBuilder
+	builds  int
+	started chan struct{}
+	release chan struct{}
+}
+
+func (b *blockingBuilder) Build(buildDir string) error {
+	b.builds++
+	if b.builds > 1 {
+		close(b.started)
+		<-b.release
+	}
+	return b.fakeBuilder.Build(buildDir)
+}
+
+func TestHandler_ServesDuringRebuild(t *testing.T) {
+	t.Parallel()
+
+	watcherCh := make(chan time.Time)
+	w := newFakeWatcher(watcherCh)
+	b := &blockingBuilder{
+		fakeBuilder: fakeBuilder{content: "build 1"},
+		started:     make(chan struct{}),
+		release:     make(chan struct{}),
+	}
+
+	h, err := handler.Start(t.Context(), w, b, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(h)
+	t.Cleanup(server.Close)
+	sCh := sseCh(t, server)
+
+	b.content = "build 2"
+	watcherCh <- time.Now()
+	<-b.started
+
+	// The previous build is served while the next one runs.
+	testRequest(t, server, &fakeBuilder{content: "build 1"})
+
+	close(b.release)
+	select {
+	case err := <-sCh:
+		if err != nil {
+			t.Fatalf("error from SSE client: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("timeout waiting for rebuild event")
+	}
+	testRequest(t, server, &fakeBuilder{content: "build 2"})
+}
@@ -0,0 +1,56 @@
+package hub
+
+import (
+	"sync"
+	"time"
+)
+
+type Hub struct {
+	mu          sync.Mutex
+	subscribers map[chan time.Time]struct{}
+}
+
+func New() *Hub {
+	return &Hub{
+		subscribers: make(map[chan time.Time]struct{}),
+	}
+}
+
+// Subscribe registers a new subscriber and returns the channel it will
+// receive events on along with a function that removes it from the hub.
+func (h *Hub) Subscribe() (<-chan time.Time, func()) {
+	ch := make(chan time.Time, 1)
+
+	h.mu.Lock()
+	h.subscribers[ch] = struct{}{}
+	h.mu.Unlock()
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			h.mu.Lock()
+			delete(h.subscribers, ch)
+			h.mu.Unlock()
+		})
+	}
+	return ch, unsubscribe
+}
+
+// Publish sends t to every subscriber. Slow subscribers that still have an
+// undelivered event pending are skipped rather than blocking the hub.
+func (h *Hub) Publish(t time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subscribers {
+		select {
+		case ch <- t:
+		default:
+		}
+	}
+}
+
+func (h *Hub) Len() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subscribers)
+}
@@ -0,0 +1,157 @@
+package hub_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/fivethirty/satisficer/internal/server/internal/handler/hub"
+)
+
+func TestHub(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		subscribers  int
+		unsubscribed int
+	}{
+		{
+			name:        "no subscribers",
+			subscribers: 0,
+		},
+		{
+			name:        "one subscriber",
+			subscribers: 1,
+		},
+		{
+			name:        "many subscribers",
+			subscribers: 10,
+		},
+		{
+			name:         "some subscribers unsubscribed",
+			subscribers:  10,
+			unsubscribed: 4,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			h := hub.New()
+			chs := make([]<-chan time.Time, 0, test.subscribers)
+			unsubs := make([]func(), 0, test.subscribers)
+			for range test.subscribers {
+				ch, unsub := h.Subscribe()
+				chs = append(chs, ch)
+				unsubs = append(unsubs, unsub)
+			}
+			for _, unsub := range unsubs[:test.unsubscribed] {
+				unsub()
+			}
+
+			wantLen := test.subscribers - test.unsubscribed
+			if h.Len() != wantLen {
+				t.Fatalf("expected %d subscribers, got %d", wantLen, h.Len())
+			}
+
+			now := time.Now()
+			h.Publish(now)
+
+			for i, ch := range chs {
+				select {
+				case got := <-ch:
+					if i < test.unsubscribed {
+						t.Fatalf("subscriber %d received event after unsubscribing", i)
+					}
+					if !got.Equal(now) {
+						t.Fatalf("expected event time %v, got %v", now, got)
+					}
+				default:
+					if i >= test.unsubscribed {
+						t.Fatalf("subscriber %d did not receive event", i)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestHub_UnsubscribeIsIdempotent(t *testing.T) {
+	t.Parallel()
+
+	h := hub.New()
+	_, unsub := h.Subscribe()
+	_, _ = h.Subscribe()
+	unsub()
+	unsub()
+	if h.Len() != 1 {
+		t.Fatalf("expected 1 subscriber, got %d", h.Len())
+	}
+}
+
+func TestHub_PublishDoesNotBlockOnSlowSubscribers(t *testing.T) {
+	t.Parallel()
+
+	h := hub.New()
+	ch, _ := h.Subscribe()
+
+	done := make(chan struct{})
+	go func() {
+		for range 5 {
+			h.Publish(time.Now())
+		}
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(100 * time.Millisecond):
+		t.Fatal("publish blocked on a slow subscriber")
+	}
+
+	select {
+	case <-ch:
+	default:
+		t.Fatal("expected a pending event")
+	}
+}
+
+func TestHub_ConcurrentSubscribers(t *testing.T) {
+	t.Parallel()
+
+	h := hub.New()
+	const subscribers = 20
+
+	var ready, done sync.WaitGroup
+	ready.Add(subscribers)
+	done.Add(subscribers)
+	errs := make(chan error, subscribers)
+	for range subscribers {
+		go func() {
+			defer done.Done()
+			ch, unsub := h.Subscribe()
+			defer unsub()
+			ready.Done()
+			select {
+			case <-ch:
+			case <-time.After(time.Second):
+				errs <- errors.New("timeout waiting for event")
+			}
+		}()
+	}
+
+	ready.Wait()
+	h.Publish(time.Now())
+	done.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Fatal(err)
+	}
+	if h.Len() != 0 {
+		t.Fatalf("expected all subscribers to be removed, got %d", h.Len())
+	}
+}
@@ -16,11 +16,17 @@ import (
 	"github.com/fivethirty/satisficer/internal/server/internal/watcher"
 )
 
-func Serve(projectFS fs.FS, port uint16) error {
-	b, err := builder.New(projectFS)
+// Serve builds the project in projectFS and serves it on port, rebuilding it
+// whenever a file changes. When port is zero, the port set in the project's
+// config file is used.
+func Serve(projectFS fs.FS, port uint16, opts builder.Options) error {
+	b, err := builder.New(projectFS, opts)
 	if err != nil {
 		return err
 	}
+	if port == 0 {
+		port = b.Port()
+	}
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 	ticker := time.NewTicker(300 * time.Millisecond)